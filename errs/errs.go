@@ -7,4 +7,35 @@ var (
 	ErrWhtMustLowerThanOrEqualIncome = errors.New("with holding tax must be lower than or equal to income")
 	ErrIncorrectAllowanceType        = errors.New("incorrect allowance type")
 	ErrEmptyCsv                      = errors.New("empty csv file given")
+	ErrUnsupportedTaxYear            = errors.New("unsupported tax year")
+	ErrInvalidTaxYear                = errors.New("invalid tax year")
+	ErrBracketsEmpty                 = errors.New("tax brackets must not be empty")
+	ErrBracketMustStartAtZero        = errors.New("first tax bracket must start at 0")
+	ErrBracketGap                    = errors.New("tax brackets must not have gaps")
+	ErrBracketOverlap                = errors.New("tax brackets must not overlap")
+	ErrBracketUpperBound             = errors.New("upper bound of tax bracket must be greater than lower bound")
+	ErrBracketNoUpperLimit           = errors.New("only the last tax bracket must have no upper bound")
+	ErrBracketRateNotMonotonic       = errors.New("tax bracket rates must not decrease")
+	ErrBracketsAlreadyExist          = errors.New("tax brackets already exist")
+	ErrBracketsNotFound              = errors.New("tax brackets not found")
+	ErrSpouseHasIncome               = errors.New("spouse allowance requires a spouse with no income")
+	ErrChildBirthYearRequired        = errors.New("child allowance requires birth year")
+	ErrParentUnderAge                = errors.New("parent must be at least 60 years old")
+	ErrParentIncomeExceeded          = errors.New("parent income exceeds the allowed limit")
+	ErrIncorrectDonationCategory     = errors.New("incorrect donation category")
+	ErrIncorrectIncomeCategory       = errors.New("incorrect income category")
+	ErrIncorrectGoalTarget           = errors.New("incorrect goal target")
+	ErrGoalUnreachable               = errors.New("goal cannot be reached")
+	ErrGoalSeekIncomes               = errors.New("goal seek supports at most one categorized income")
+	ErrIncorrectMonth                = errors.New("month must be between 1 and 12")
+	ErrFilersIncomeMismatch          = errors.New("both filers must give either total income or categorized incomes")
+	ErrIncorrectCorporateRate        = errors.New("corporate tax rate must be at least 0 and below 1")
+	ErrIncorrectDividendElection     = errors.New("incorrect dividend election")
+	ErrYearsOfServiceRequired        = errors.New("years of service must be greater than 0")
+	ErrIncomeDateRequired            = errors.New("foreign currency income requires a date")
+	ErrExchangeRateNotFound          = errors.New("exchange rate not found")
+	ErrIncorrectResidency            = errors.New("incorrect residency")
+	ErrIncorrectDaysInThailand       = errors.New("days in Thailand must be between 0 and the days of the tax year")
+	ErrDaysInThailandRequired        = errors.New("non-residents require days in Thailand")
+	ErrResidencyMismatch             = errors.New("residency does not match days in Thailand")
 )
@@ -7,3 +7,24 @@ CREATE TABLE IF NOT EXISTS tax_deduction_configs (
 );
 
 INSERT INTO tax_deduction_configs (personal, kreceipt) VALUES (60000.00, 50000.000);
+
+CREATE TABLE IF NOT EXISTS tax_brackets (
+    id SERIAL PRIMARY KEY,
+    tax_year INTEGER NOT NULL,
+    lower_bound DECIMAL(15, 2) NOT NULL,
+    upper_bound DECIMAL(15, 2),
+    rate DECIMAL(5, 4) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tax_year, lower_bound)
+);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id SERIAL PRIMARY KEY,
+    currency CHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate DECIMAL(15, 6) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (currency, rate_date)
+);
@@ -1,19 +1,41 @@
 package models
 
-import "time"
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
 
 type DeductionConfigEntity struct {
-	ID        int       `postgres:"id"`
-	Personal  float64   `postgres:"personal"`
-	KReceipt  float64   `postgres:"kreceipt"`
-	CreatedAt time.Time `postgres:"created_at"`
-	UpdatedAt time.Time `postgres:"updated_at"`
+	ID        int             `postgres:"id"`
+	Personal  decimal.Decimal `postgres:"personal"`
+	KReceipt  decimal.Decimal `postgres:"kreceipt"`
+	CreatedAt time.Time       `postgres:"created_at"`
+	UpdatedAt time.Time       `postgres:"updated_at"`
 }
 
 type DeductionConfig struct {
-	ID        int       `postgres:"id"`
-	Personal  float64   `postgres:"personal"`
-	KReceipt  float64   `postgres:"kreceipt"`
-	CreatedAt time.Time `postgres:"created_at"`
-	UpdatedAt time.Time `postgres:"updated_at"`
+	ID        int             `postgres:"id"`
+	Personal  decimal.Decimal `postgres:"personal"`
+	KReceipt  decimal.Decimal `postgres:"kreceipt"`
+	CreatedAt time.Time       `postgres:"created_at"`
+	UpdatedAt time.Time       `postgres:"updated_at"`
+}
+
+type TaxBracket struct {
+	ID        int              `postgres:"id"`
+	TaxYear   int              `postgres:"tax_year"`
+	Lower     decimal.Decimal  `postgres:"lower_bound"`
+	Upper     *decimal.Decimal `postgres:"upper_bound"`
+	Rate      decimal.Decimal  `postgres:"rate"`
+	CreatedAt time.Time        `postgres:"created_at"`
+	UpdatedAt time.Time        `postgres:"updated_at"`
+}
+
+type ExchangeRate struct {
+	ID        int             `postgres:"id"`
+	Currency  string          `postgres:"currency"`
+	Date      time.Time       `postgres:"rate_date"`
+	Rate      decimal.Decimal `postgres:"rate"`
+	CreatedAt time.Time       `postgres:"created_at"`
+	UpdatedAt time.Time       `postgres:"updated_at"`
 }
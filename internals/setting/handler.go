@@ -1,32 +1,83 @@
 package setting
 
 import (
+	"database/sql"
+	"errors"
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/internals/models"
 	"github.com/Atvit/assessment-tax/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type PersonalDeductionRequest struct {
-	Amount float64 `json:"amount" validate:"required,lte=100000,gte=10000"`
+	Amount decimal.Decimal `json:"amount" validate:"required,lte=100000,gte=10000"`
 }
 
 type PersonalDeductionResponse struct {
-	PersonalDeduction float64 `json:"personalDeduction"`
+	PersonalDeduction decimal.Decimal `json:"personalDeduction"`
 }
 
 type KReceiptDeductionRequest struct {
-	Amount float64 `json:"amount" validate:"required,lte=100000,gt=0"`
+	Amount decimal.Decimal `json:"amount" validate:"required,lte=100000,gt=0"`
 }
 
 type KReceiptDeductionResponse struct {
-	KReceipt float64 `json:"kReceipt"`
+	KReceipt decimal.Decimal `json:"kReceipt"`
+}
+
+type BracketRequest struct {
+	Lower decimal.Decimal  `json:"lower" validate:"gte=0"`
+	Upper *decimal.Decimal `json:"upper" validate:"omitempty,gt=0"`
+	Rate  decimal.Decimal  `json:"rate" validate:"gte=0,lte=1"`
+}
+
+type BracketsRequest struct {
+	Brackets []BracketRequest `json:"brackets" validate:"required,dive"`
+}
+
+type BracketResponse struct {
+	Lower decimal.Decimal  `json:"lower"`
+	Upper *decimal.Decimal `json:"upper"`
+	Rate  decimal.Decimal  `json:"rate"`
+}
+
+type BracketsResponse struct {
+	TaxYear  int               `json:"taxYear"`
+	Brackets []BracketResponse `json:"brackets"`
+}
+
+type ExchangeRateCSV struct {
+	Currency string          `csv:"currency" validate:"required,len=3"`
+	Date     string          `csv:"date" validate:"required,datetime=2006-01-02"`
+	Rate     decimal.Decimal `csv:"rate" validate:"required,gt=0"`
+}
+
+type ExchangeRateResponse struct {
+	Currency string          `json:"currency"`
+	Date     string          `json:"date"`
+	Rate     decimal.Decimal `json:"rate"`
+}
+
+type ExchangeRatesResponse struct {
+	Rates []ExchangeRateResponse `json:"rates"`
 }
 
 type Handler interface {
 	UpdatePersonalDeduction(c echo.Context) error
 	UpdateKReceiptDeduction(c echo.Context) error
+	GetBrackets(c echo.Context) error
+	CreateBrackets(c echo.Context) error
+	UpdateBrackets(c echo.Context) error
+	DeleteBrackets(c echo.Context) error
+	UploadExchangeRates(c echo.Context) error
 }
 
 type handler struct {
@@ -102,3 +153,302 @@ func (h handler) UpdateKReceiptDeduction(c echo.Context) error {
 		KReceipt: result.KReceipt,
 	})
 }
+
+func (h handler) GetBrackets(c echo.Context) error {
+	taxYear, err := getTaxYearParam(c)
+	if err != nil {
+		h.logger.Error("parse tax year failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	brackets, err := h.repository.GetBrackets(taxYear)
+	if err != nil {
+		h.logger.Error("get tax brackets failed", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if len(brackets) == 0 {
+		return c.JSON(http.StatusNotFound, utils.ErrResponse{
+			Error: errs.ErrBracketsNotFound.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, newBracketsResponse(taxYear, brackets))
+}
+
+func (h handler) CreateBrackets(c echo.Context) error {
+	taxYear, err := getTaxYearParam(c)
+	if err != nil {
+		h.logger.Error("parse tax year failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	var req BracketsRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.logger.Error("validate request body failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: utils.GetValidateErrMsg(err),
+		})
+	}
+
+	brackets := toTaxBrackets(taxYear, req.Brackets)
+	if err := validateBrackets(brackets); err != nil {
+		h.logger.Error("validate tax brackets failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	result, err := h.repository.CreateBrackets(taxYear, brackets)
+	if errors.Is(err, errs.ErrBracketsAlreadyExist) {
+		return c.JSON(http.StatusConflict, utils.ErrResponse{
+			Error: errs.ErrBracketsAlreadyExist.Error(),
+		})
+	}
+
+	if err != nil {
+		h.logger.Error("create tax brackets failed", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, newBracketsResponse(taxYear, result))
+}
+
+func (h handler) UpdateBrackets(c echo.Context) error {
+	taxYear, err := getTaxYearParam(c)
+	if err != nil {
+		h.logger.Error("parse tax year failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	var req BracketsRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.logger.Error("validate request body failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: utils.GetValidateErrMsg(err),
+		})
+	}
+
+	brackets := toTaxBrackets(taxYear, req.Brackets)
+	if err := validateBrackets(brackets); err != nil {
+		h.logger.Error("validate tax brackets failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	result, err := h.repository.ReplaceBrackets(taxYear, brackets)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, utils.ErrResponse{
+			Error: errs.ErrBracketsNotFound.Error(),
+		})
+	}
+
+	if err != nil {
+		h.logger.Error("update tax brackets failed", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, newBracketsResponse(taxYear, result))
+}
+
+func (h handler) DeleteBrackets(c echo.Context) error {
+	taxYear, err := getTaxYearParam(c)
+	if err != nil {
+		h.logger.Error("parse tax year failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	err = h.repository.DeleteBrackets(taxYear)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, utils.ErrResponse{
+			Error: errs.ErrBracketsNotFound.Error(),
+		})
+	}
+
+	if err != nil {
+		h.logger.Error("delete tax brackets failed", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h handler) UploadExchangeRates(c echo.Context) error {
+	file, err := c.FormFile("rateFile")
+	if err != nil {
+		h.logger.Error("upload file failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	var data []ExchangeRateCSV
+	csvData, err := utils.ReadCSV(file, data)
+	if err != nil {
+		h.logger.Error("read csv failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if len(csvData) == 0 {
+		h.logger.Error("empty csv file", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: errs.ErrEmptyCsv.Error(),
+		})
+	}
+
+	rates := make([]models.ExchangeRate, 0, len(csvData))
+	for _, v := range csvData {
+		if err := h.validate.Struct(v); err != nil {
+			h.logger.Error("validate csv record failed", zap.Error(err))
+			return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+				Error: utils.GetValidateErrMsg(err),
+			})
+		}
+
+		date, _ := time.Parse(time.DateOnly, v.Date)
+		rates = append(rates, models.ExchangeRate{
+			Currency: strings.ToUpper(v.Currency),
+			Date:     date,
+			Rate:     v.Rate,
+		})
+	}
+
+	result, err := h.repository.SaveExchangeRates(rates)
+	if err != nil {
+		h.logger.Error("save exchange rates failed", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	resp := ExchangeRatesResponse{Rates: make([]ExchangeRateResponse, 0, len(result))}
+	for _, rate := range result {
+		resp.Rates = append(resp.Rates, ExchangeRateResponse{
+			Currency: rate.Currency,
+			Date:     rate.Date.Format(time.DateOnly),
+			Rate:     rate.Rate,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func toTaxBrackets(taxYear int, req []BracketRequest) []models.TaxBracket {
+	brackets := make([]models.TaxBracket, 0, len(req))
+	for _, bracket := range req {
+		brackets = append(brackets, models.TaxBracket{
+			TaxYear: taxYear,
+			Lower:   bracket.Lower,
+			Upper:   bracket.Upper,
+			Rate:    bracket.Rate,
+		})
+	}
+
+	return brackets
+}
+
+func getTaxYearParam(c echo.Context) (int, error) {
+	taxYear, err := strconv.Atoi(c.Param("taxYear"))
+	if err != nil || taxYear <= 0 {
+		return 0, errs.ErrInvalidTaxYear
+	}
+
+	return taxYear, nil
+}
+
+func validateBrackets(brackets []models.TaxBracket) error {
+	if len(brackets) == 0 {
+		return errs.ErrBracketsEmpty
+	}
+
+	sort.Slice(brackets, func(i, j int) bool {
+		return brackets[i].Lower.LessThan(brackets[j].Lower)
+	})
+
+	if !brackets[0].Lower.IsZero() {
+		return errs.ErrBracketMustStartAtZero
+	}
+
+	for i, bracket := range brackets {
+		last := i == len(brackets)-1
+
+		if last != (bracket.Upper == nil) {
+			return errs.ErrBracketNoUpperLimit
+		}
+
+		if bracket.Upper != nil && bracket.Upper.LessThanOrEqual(bracket.Lower) {
+			return errs.ErrBracketUpperBound
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		prev := brackets[i-1]
+		if bracket.Lower.GreaterThan(*prev.Upper) {
+			return errs.ErrBracketGap
+		}
+
+		if bracket.Lower.LessThan(*prev.Upper) {
+			return errs.ErrBracketOverlap
+		}
+
+		if bracket.Rate.LessThan(prev.Rate) {
+			return errs.ErrBracketRateNotMonotonic
+		}
+	}
+
+	return nil
+}
+
+func newBracketsResponse(taxYear int, brackets []models.TaxBracket) BracketsResponse {
+	resp := BracketsResponse{
+		TaxYear:  taxYear,
+		Brackets: make([]BracketResponse, 0, len(brackets)),
+	}
+
+	for _, bracket := range brackets {
+		resp.Brackets = append(resp.Brackets, BracketResponse{
+			Lower: bracket.Lower,
+			Upper: bracket.Upper,
+			Rate:  bracket.Rate,
+		})
+	}
+
+	return resp
+}
@@ -3,18 +3,29 @@ package setting
 import (
 	"bytes"
 	"database/sql"
+	"github.com/Atvit/assessment-tax/errs"
 	"github.com/Atvit/assessment-tax/internals/models"
 	mockSetting "github.com/Atvit/assessment-tax/mocks/setting"
-	"github.com/go-playground/validator/v10"
+	"github.com/Atvit/assessment-tax/utils"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 )
 
+func TestMain(m *testing.M) {
+	// the server writes amounts to JSON as numbers, see main
+	decimal.MarshalJSONWithoutQuotes = true
+	os.Exit(m.Run())
+}
+
 func TestHandler_UpdatePersonalDeduction(t *testing.T) {
 	type testcase struct {
 		requestBody    []byte
@@ -24,7 +35,7 @@ func TestHandler_UpdatePersonalDeduction(t *testing.T) {
 
 	e := echo.New()
 	logger := zap.NewNop()
-	validate := validator.New()
+	validate := utils.NewValidator()
 	repo := new(mockSetting.Repository)
 
 	h := &handler{
@@ -45,7 +56,7 @@ func TestHandler_UpdatePersonalDeduction(t *testing.T) {
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		repo.On("UpdatePersonalDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("float64")).Return(&models.DeductionConfig{ID: 1, Personal: 70000, KReceipt: 50000}, nil).Once()
+		repo.On("UpdatePersonalDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("decimal.Decimal")).Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(70000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
 
 		if assert.NoError(t, h.UpdatePersonalDeduction(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
@@ -119,7 +130,7 @@ func TestHandler_UpdatePersonalDeduction(t *testing.T) {
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		repo.On("UpdatePersonalDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("float64")).Return(&models.DeductionConfig{ID: 1, Personal: 10000, KReceipt: 50000}, nil).Once()
+		repo.On("UpdatePersonalDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("decimal.Decimal")).Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(10000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
 
 		if assert.NoError(t, h.UpdatePersonalDeduction(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
@@ -139,7 +150,7 @@ func TestHandler_UpdatePersonalDeduction(t *testing.T) {
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		repo.On("UpdatePersonalDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("float64")).Return(&models.DeductionConfig{ID: 1, Personal: 100000, KReceipt: 50000}, nil).Once()
+		repo.On("UpdatePersonalDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("decimal.Decimal")).Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(100000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
 
 		if assert.NoError(t, h.UpdatePersonalDeduction(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
@@ -160,7 +171,7 @@ func TestHandler_UpdatePersonalDeduction(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		errNoRows := sql.ErrNoRows
-		repo.On("UpdatePersonalDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("float64")).Return(nil, errNoRows).Once()
+		repo.On("UpdatePersonalDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("decimal.Decimal")).Return(nil, errNoRows).Once()
 
 		if assert.NoError(t, h.UpdatePersonalDeduction(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
@@ -178,7 +189,7 @@ func TestHandler_UpdateKReceiptDeduction(t *testing.T) {
 
 	e := echo.New()
 	logger := zap.NewNop()
-	validate := validator.New()
+	validate := utils.NewValidator()
 	repo := new(mockSetting.Repository)
 
 	h := &handler{
@@ -199,7 +210,7 @@ func TestHandler_UpdateKReceiptDeduction(t *testing.T) {
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		repo.On("UpdateKReceiptDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("float64")).Return(&models.DeductionConfig{ID: 1, Personal: 70000, KReceipt: 70000}, nil).Once()
+		repo.On("UpdateKReceiptDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("decimal.Decimal")).Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(70000), KReceipt: decimal.NewFromInt(70000)}, nil).Once()
 
 		if assert.NoError(t, h.UpdateKReceiptDeduction(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
@@ -292,7 +303,7 @@ func TestHandler_UpdateKReceiptDeduction(t *testing.T) {
 		c := e.NewContext(req, rec)
 
 		errNoRows := sql.ErrNoRows
-		repo.On("UpdateKReceiptDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("float64")).Return(nil, errNoRows).Once()
+		repo.On("UpdateKReceiptDeduction", mock.AnythingOfType("uint"), mock.AnythingOfType("decimal.Decimal")).Return(nil, errNoRows).Once()
 
 		if assert.NoError(t, h.UpdateKReceiptDeduction(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
@@ -300,3 +311,632 @@ func TestHandler_UpdateKReceiptDeduction(t *testing.T) {
 		}
 	})
 }
+
+func TestHandler_GetBrackets(t *testing.T) {
+	type testcase struct {
+		taxYear        string
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	repo := new(mockSetting.Repository)
+
+	h := &handler{
+		logger:     logger,
+		validate:   validate,
+		repository: repo,
+	}
+
+	t.Run("valid request", func(t *testing.T) {
+		tc := testcase{
+			taxYear:        "2567",
+			expectedStatus: 200,
+			expectedBody:   `{"taxYear":2567,"brackets":[{"lower":0,"upper":150000,"rate":0},{"lower":150000,"upper":null,"rate":0.1}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/brackets/"+tc.taxYear, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues(tc.taxYear)
+
+		repo.On("GetBrackets", 2567).Return([]models.TaxBracket{
+			{ID: 1, TaxYear: 2567, Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(150000)), Rate: decimal.Zero},
+			{ID: 2, TaxYear: 2567, Lower: decimal.NewFromInt(150000), Rate: decimal.RequireFromString("0.1")},
+		}, nil).Once()
+
+		if assert.NoError(t, h.GetBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid tax year", func(t *testing.T) {
+		tc := testcase{
+			taxYear:        "abc",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"invalid tax year"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/brackets/"+tc.taxYear, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues(tc.taxYear)
+
+		if assert.NoError(t, h.GetBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		tc := testcase{
+			taxYear:        "2566",
+			expectedStatus: 404,
+			expectedBody:   `{"error":"tax brackets not found"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/brackets/"+tc.taxYear, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues(tc.taxYear)
+
+		repo.On("GetBrackets", 2566).Return(nil, nil).Once()
+
+		if assert.NoError(t, h.GetBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		tc := testcase{
+			taxYear:        "2567",
+			expectedStatus: 500,
+			expectedBody:   `{"error":"sql: connection is already closed"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/brackets/"+tc.taxYear, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues(tc.taxYear)
+
+		repo.On("GetBrackets", 2567).Return(nil, sql.ErrConnDone).Once()
+
+		if assert.NoError(t, h.GetBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestHandler_CreateBrackets(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	repo := new(mockSetting.Repository)
+
+	h := &handler{
+		logger:     logger,
+		validate:   validate,
+		repository: repo,
+	}
+
+	t.Run("valid request", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"brackets":[{"lower":150000,"rate":0.1},{"lower":0,"upper":150000,"rate":0}]}`),
+			expectedStatus: 201,
+			expectedBody:   `{"taxYear":2568,"brackets":[{"lower":0,"upper":150000,"rate":0},{"lower":150000,"upper":null,"rate":0.1}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/brackets/2568", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues("2568")
+
+		repo.On("CreateBrackets", 2568, mock.AnythingOfType("[]models.TaxBracket")).Return([]models.TaxBracket{
+			{ID: 1, TaxYear: 2568, Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(150000)), Rate: decimal.Zero},
+			{ID: 2, TaxYear: 2568, Lower: decimal.NewFromInt(150000), Rate: decimal.RequireFromString("0.1")},
+		}, nil).Once()
+
+		if assert.NoError(t, h.CreateBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("already exist", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"brackets":[{"lower":0,"upper":150000,"rate":0},{"lower":150000,"rate":0.1}]}`),
+			expectedStatus: 409,
+			expectedBody:   `{"error":"tax brackets already exist"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/brackets/2567", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues("2567")
+
+		repo.On("CreateBrackets", 2567, mock.AnythingOfType("[]models.TaxBracket")).Return(nil, errs.ErrBracketsAlreadyExist).Once()
+
+		if assert.NoError(t, h.CreateBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("gap between brackets", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"brackets":[{"lower":0,"upper":150000,"rate":0},{"lower":200000,"rate":0.1}]}`),
+			expectedStatus: 400,
+			expectedBody:   `{"error":"tax brackets must not have gaps"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/brackets/2567", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues("2567")
+
+		if assert.NoError(t, h.CreateBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("rate greater than 1", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"brackets":[{"lower":0,"rate":1.5}]}`),
+			expectedStatus: 400,
+			expectedBody:   `{"error":[{"field":"Rate","message":"the value of Rate must be less than or equal 1"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/brackets/2567", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues("2567")
+
+		if assert.NoError(t, h.CreateBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("create db error", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"brackets":[{"lower":0,"rate":0.1}]}`),
+			expectedStatus: 500,
+			expectedBody:   `{"error":"sql: connection is already closed"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/brackets/2567", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues("2567")
+
+		repo.On("CreateBrackets", 2567, mock.AnythingOfType("[]models.TaxBracket")).Return(nil, sql.ErrConnDone).Once()
+
+		if assert.NoError(t, h.CreateBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestHandler_UpdateBrackets(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	repo := new(mockSetting.Repository)
+
+	h := &handler{
+		logger:     logger,
+		validate:   validate,
+		repository: repo,
+	}
+
+	t.Run("valid request", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"brackets":[{"lower":0,"upper":200000,"rate":0},{"lower":200000,"rate":0.2}]}`),
+			expectedStatus: 200,
+			expectedBody:   `{"taxYear":2567,"brackets":[{"lower":0,"upper":200000,"rate":0},{"lower":200000,"upper":null,"rate":0.2}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPut, "/admin/brackets/2567", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues("2567")
+
+		repo.On("ReplaceBrackets", 2567, mock.AnythingOfType("[]models.TaxBracket")).Return([]models.TaxBracket{
+			{ID: 3, TaxYear: 2567, Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(200000)), Rate: decimal.Zero},
+			{ID: 4, TaxYear: 2567, Lower: decimal.NewFromInt(200000), Rate: decimal.RequireFromString("0.2")},
+		}, nil).Once()
+
+		if assert.NoError(t, h.UpdateBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"brackets":[{"lower":0,"rate":0.1}]}`),
+			expectedStatus: 404,
+			expectedBody:   `{"error":"tax brackets not found"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPut, "/admin/brackets/2566", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues("2566")
+
+		repo.On("ReplaceBrackets", 2566, mock.AnythingOfType("[]models.TaxBracket")).Return(nil, sql.ErrNoRows).Once()
+
+		if assert.NoError(t, h.UpdateBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("decreasing rates", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"brackets":[{"lower":0,"upper":150000,"rate":0.1},{"lower":150000,"rate":0.05}]}`),
+			expectedStatus: 400,
+			expectedBody:   `{"error":"tax bracket rates must not decrease"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPut, "/admin/brackets/2567", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues("2567")
+
+		if assert.NoError(t, h.UpdateBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestHandler_DeleteBrackets(t *testing.T) {
+	type testcase struct {
+		taxYear        string
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	repo := new(mockSetting.Repository)
+
+	h := &handler{
+		logger:     logger,
+		validate:   validate,
+		repository: repo,
+	}
+
+	t.Run("valid request", func(t *testing.T) {
+		tc := testcase{
+			taxYear:        "2567",
+			expectedStatus: 204,
+			expectedBody:   "",
+		}
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/brackets/"+tc.taxYear, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues(tc.taxYear)
+
+		repo.On("DeleteBrackets", 2567).Return(nil).Once()
+
+		if assert.NoError(t, h.DeleteBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		tc := testcase{
+			taxYear:        "2566",
+			expectedStatus: 404,
+			expectedBody:   `{"error":"tax brackets not found"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/brackets/"+tc.taxYear, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues(tc.taxYear)
+
+		repo.On("DeleteBrackets", 2566).Return(sql.ErrNoRows).Once()
+
+		if assert.NoError(t, h.DeleteBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		tc := testcase{
+			taxYear:        "2568",
+			expectedStatus: 500,
+			expectedBody:   `{"error":"sql: connection is already closed"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/brackets/"+tc.taxYear, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("taxYear")
+		c.SetParamValues(tc.taxYear)
+
+		repo.On("DeleteBrackets", 2568).Return(sql.ErrConnDone).Once()
+
+		if assert.NoError(t, h.DeleteBrackets(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestHandler_UploadExchangeRates(t *testing.T) {
+	type testcase struct {
+		fileContent    string
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	repo := new(mockSetting.Repository)
+
+	h := &handler{
+		logger:     logger,
+		validate:   validate,
+		repository: repo,
+	}
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("valid CSV file", func(t *testing.T) {
+		tc := testcase{
+			fileContent:    "currency,date,rate\nusd,2024-05-01,36.5\nEUR,2024-05-01,39.2",
+			expectedStatus: 200,
+			expectedBody:   `{"rates":[{"currency":"USD","date":"2024-05-01","rate":36.5},{"currency":"EUR","date":"2024-05-01","rate":39.2}]}`,
+		}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("rateFile", "rates.csv")
+		part.Write([]byte(tc.fileContent))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/exchange-rates", body)
+		req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		repo.On("SaveExchangeRates", []models.ExchangeRate{
+			{Currency: "USD", Date: date, Rate: decimal.RequireFromString("36.5")},
+			{Currency: "EUR", Date: date, Rate: decimal.RequireFromString("39.2")},
+		}).Return([]models.ExchangeRate{
+			{ID: 1, Currency: "USD", Date: date, Rate: decimal.RequireFromString("36.5")},
+			{ID: 2, Currency: "EUR", Date: date, Rate: decimal.RequireFromString("39.2")},
+		}, nil).Once()
+
+		if assert.NoError(t, h.UploadExchangeRates(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("empty CSV file", func(t *testing.T) {
+		tc := testcase{
+			fileContent:    "currency,date,rate",
+			expectedStatus: 400,
+			expectedBody:   `{"error":"empty csv file given"}`,
+		}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("rateFile", "rates.csv")
+		part.Write([]byte(tc.fileContent))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/exchange-rates", body)
+		req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, h.UploadExchangeRates(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		tc := testcase{
+			fileContent:    "currency,date,rate\nUSD,01/05/2024,36.5",
+			expectedStatus: 400,
+			expectedBody:   `{"error":[{"field":"Date","message":"the value of Date must be a date in format 2006-01-02"}]}`,
+		}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("rateFile", "rates.csv")
+		part.Write([]byte(tc.fileContent))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/exchange-rates", body)
+		req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, h.UploadExchangeRates(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid currency", func(t *testing.T) {
+		tc := testcase{
+			fileContent:    "currency,date,rate\nUS,2024-05-01,36.5",
+			expectedStatus: 400,
+			expectedBody:   `{"error":[{"field":"Currency","message":"the length of Currency must be 3"}]}`,
+		}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("rateFile", "rates.csv")
+		part.Write([]byte(tc.fileContent))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/exchange-rates", body)
+		req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, h.UploadExchangeRates(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		tc := testcase{
+			fileContent:    "currency,date,rate\nUSD,2024-05-02,36.6",
+			expectedStatus: 500,
+			expectedBody:   `{"error":"sql: connection is already closed"}`,
+		}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("rateFile", "rates.csv")
+		part.Write([]byte(tc.fileContent))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/exchange-rates", body)
+		req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		repo.On("SaveExchangeRates", mock.Anything).Return(nil, sql.ErrConnDone).Once()
+
+		if assert.NoError(t, h.UploadExchangeRates(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestValidateBrackets(t *testing.T) {
+	tests := []struct {
+		name        string
+		brackets    []models.TaxBracket
+		expectedErr error
+	}{
+		{
+			name: "valid brackets",
+			brackets: []models.TaxBracket{
+				{Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(150000)), Rate: decimal.Zero},
+				{Lower: decimal.NewFromInt(150000), Upper: utils.ToPointer(decimal.NewFromInt(500000)), Rate: decimal.RequireFromString("0.1")},
+				{Lower: decimal.NewFromInt(500000), Rate: decimal.RequireFromString("0.1")},
+			},
+		},
+		{
+			name:        "empty brackets",
+			brackets:    []models.TaxBracket{},
+			expectedErr: errs.ErrBracketsEmpty,
+		},
+		{
+			name:        "not start at zero",
+			brackets:    []models.TaxBracket{{Lower: decimal.NewFromInt(100), Rate: decimal.RequireFromString("0.1")}},
+			expectedErr: errs.ErrBracketMustStartAtZero,
+		},
+		{
+			name: "gap",
+			brackets: []models.TaxBracket{
+				{Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(150000)), Rate: decimal.Zero},
+				{Lower: decimal.NewFromInt(150001), Rate: decimal.RequireFromString("0.1")},
+			},
+			expectedErr: errs.ErrBracketGap,
+		},
+		{
+			name: "overlap",
+			brackets: []models.TaxBracket{
+				{Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(150000)), Rate: decimal.Zero},
+				{Lower: decimal.NewFromInt(100000), Rate: decimal.RequireFromString("0.1")},
+			},
+			expectedErr: errs.ErrBracketOverlap,
+		},
+		{
+			name: "upper bound lower than lower bound",
+			brackets: []models.TaxBracket{
+				{Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(150000)), Rate: decimal.Zero},
+				{Lower: decimal.NewFromInt(150000), Upper: utils.ToPointer(decimal.NewFromInt(100000)), Rate: decimal.RequireFromString("0.1")},
+				{Lower: decimal.NewFromInt(500000), Rate: decimal.RequireFromString("0.1")},
+			},
+			expectedErr: errs.ErrBracketUpperBound,
+		},
+		{
+			name:        "last bracket has upper bound",
+			brackets:    []models.TaxBracket{{Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(150000)), Rate: decimal.Zero}},
+			expectedErr: errs.ErrBracketNoUpperLimit,
+		},
+		{
+			name: "middle bracket has no upper bound",
+			brackets: []models.TaxBracket{
+				{Lower: decimal.Zero, Rate: decimal.Zero},
+				{Lower: decimal.NewFromInt(150000), Rate: decimal.RequireFromString("0.1")},
+			},
+			expectedErr: errs.ErrBracketNoUpperLimit,
+		},
+		{
+			name: "decreasing rate",
+			brackets: []models.TaxBracket{
+				{Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(150000)), Rate: decimal.RequireFromString("0.1")},
+				{Lower: decimal.NewFromInt(150000), Rate: decimal.Zero},
+			},
+			expectedErr: errs.ErrBracketRateNotMonotonic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedErr, validateBrackets(tt.brackets))
+		})
+	}
+}
@@ -2,7 +2,9 @@ package setting
 
 import (
 	"database/sql"
+	"github.com/Atvit/assessment-tax/errs"
 	"github.com/Atvit/assessment-tax/internals/models"
+	"github.com/shopspring/decimal"
 	"time"
 )
 
@@ -10,12 +12,25 @@ const (
 	getStmt            = "SELECT * FROM tax_deduction_configs ORDER BY updated_at DESC LIMIT 1"
 	updatePersonalStmt = "UPDATE tax_deduction_configs SET personal = $1, updated_at = $2 WHERE id = $3 RETURNING *"
 	updateKReceiptStmt = "UPDATE tax_deduction_configs SET kreceipt = $1, updated_at = $2 WHERE id = $3 RETURNING *"
+	getBracketsStmt    = "SELECT * FROM tax_brackets WHERE tax_year = $1 ORDER BY lower_bound"
+	insertBracketStmt  = "INSERT INTO tax_brackets (tax_year, lower_bound, upper_bound, rate) VALUES ($1, $2, $3, $4) RETURNING *"
+	deleteBracketsStmt = "DELETE FROM tax_brackets WHERE tax_year = $1"
+	lockBracketsStmt   = "LOCK TABLE tax_brackets IN SHARE ROW EXCLUSIVE MODE"
+	countBracketsStmt  = "SELECT COUNT(*) FROM tax_brackets WHERE tax_year = $1"
+	getRateStmt        = "SELECT * FROM exchange_rates WHERE currency = $1 AND rate_date <= $2 ORDER BY rate_date DESC LIMIT 1"
+	upsertRateStmt     = "INSERT INTO exchange_rates (currency, rate_date, rate) VALUES ($1, $2, $3) ON CONFLICT (currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate, updated_at = $4 RETURNING *"
 )
 
 type Repository interface {
 	Get() (*models.DeductionConfig, error)
-	UpdatePersonalDeduction(id uint, value float64) (*models.DeductionConfig, error)
-	UpdateKReceiptDeduction(id uint, value float64) (*models.DeductionConfig, error)
+	UpdatePersonalDeduction(id uint, value decimal.Decimal) (*models.DeductionConfig, error)
+	UpdateKReceiptDeduction(id uint, value decimal.Decimal) (*models.DeductionConfig, error)
+	GetBrackets(taxYear int) ([]models.TaxBracket, error)
+	CreateBrackets(taxYear int, brackets []models.TaxBracket) ([]models.TaxBracket, error)
+	ReplaceBrackets(taxYear int, brackets []models.TaxBracket) ([]models.TaxBracket, error)
+	DeleteBrackets(taxYear int) error
+	GetExchangeRate(currency string, date time.Time) (*models.ExchangeRate, error)
+	SaveExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error)
 }
 
 type repository struct {
@@ -46,7 +61,7 @@ func (r repository) Get() (*models.DeductionConfig, error) {
 	return &config, nil
 }
 
-func (r repository) UpdatePersonalDeduction(id uint, value float64) (*models.DeductionConfig, error) {
+func (r repository) UpdatePersonalDeduction(id uint, value decimal.Decimal) (*models.DeductionConfig, error) {
 	row := r.db.QueryRow(updatePersonalStmt, value, time.Now(), id)
 
 	var result models.DeductionConfig
@@ -58,7 +73,7 @@ func (r repository) UpdatePersonalDeduction(id uint, value float64) (*models.Ded
 	return &result, nil
 }
 
-func (r repository) UpdateKReceiptDeduction(id uint, value float64) (*models.DeductionConfig, error) {
+func (r repository) UpdateKReceiptDeduction(id uint, value decimal.Decimal) (*models.DeductionConfig, error) {
 	row := r.db.QueryRow(updateKReceiptStmt, value, time.Now(), id)
 
 	var result models.DeductionConfig
@@ -69,3 +84,153 @@ func (r repository) UpdateKReceiptDeduction(id uint, value float64) (*models.Ded
 
 	return &result, nil
 }
+
+func (r repository) GetBrackets(taxYear int) ([]models.TaxBracket, error) {
+	rows, err := r.db.Query(getBracketsStmt, taxYear)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var brackets []models.TaxBracket
+	for rows.Next() {
+		var bracket models.TaxBracket
+		err := rows.Scan(&bracket.ID, &bracket.TaxYear, &bracket.Lower, &bracket.Upper, &bracket.Rate, &bracket.CreatedAt, &bracket.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		brackets = append(brackets, bracket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return brackets, nil
+}
+
+// CreateBrackets saves the brackets of a tax year that has none, or returns
+// errs.ErrBracketsAlreadyExist.
+func (r repository) CreateBrackets(taxYear int, brackets []models.TaxBracket) ([]models.TaxBracket, error) {
+	return r.saveBrackets(taxYear, brackets, false)
+}
+
+// ReplaceBrackets replaces the brackets of a tax year, or returns
+// sql.ErrNoRows when it has none.
+func (r repository) ReplaceBrackets(taxYear int, brackets []models.TaxBracket) ([]models.TaxBracket, error) {
+	return r.saveBrackets(taxYear, brackets, true)
+}
+
+func (r repository) DeleteBrackets(taxYear int) error {
+	result, err := r.db.Exec(deleteBracketsStmt, taxYear)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r repository) saveBrackets(taxYear int, brackets []models.TaxBracket, replace bool) ([]models.TaxBracket, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// the lock makes checking for existing brackets and saving them one step
+	// for concurrent requests, reads are not blocked
+	if _, err := tx.Exec(lockBracketsStmt); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := tx.QueryRow(countBracketsStmt, taxYear).Scan(&count); err != nil {
+		return nil, err
+	}
+
+	if !replace && count > 0 {
+		return nil, errs.ErrBracketsAlreadyExist
+	}
+
+	if replace && count == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	if replace {
+		if _, err := tx.Exec(deleteBracketsStmt, taxYear); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []models.TaxBracket
+	for _, bracket := range brackets {
+		row := tx.QueryRow(insertBracketStmt, taxYear, bracket.Lower, bracket.Upper, bracket.Rate)
+
+		var saved models.TaxBracket
+		err := row.Scan(&saved.ID, &saved.TaxYear, &saved.Lower, &saved.Upper, &saved.Rate, &saved.CreatedAt, &saved.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, saved)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetExchangeRate returns the latest rate of the currency on or before the
+// date, or sql.ErrNoRows when there is none.
+func (r repository) GetExchangeRate(currency string, date time.Time) (*models.ExchangeRate, error) {
+	row := r.db.QueryRow(getRateStmt, currency, date)
+
+	var rate models.ExchangeRate
+	err := row.Scan(&rate.ID, &rate.Currency, &rate.Date, &rate.Rate, &rate.CreatedAt, &rate.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+// SaveExchangeRates inserts the rates in one transaction, replacing the rate of
+// a currency already stored for the same date.
+func (r repository) SaveExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var result []models.ExchangeRate
+	for _, rate := range rates {
+		row := tx.QueryRow(upsertRateStmt, rate.Currency, rate.Date, rate.Rate, time.Now())
+
+		var saved models.ExchangeRate
+		err := row.Scan(&saved.ID, &saved.Currency, &saved.Date, &saved.Rate, &saved.CreatedAt, &saved.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, saved)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -3,8 +3,10 @@ package setting
 import (
 	"database/sql"
 	"errors"
+	"github.com/Atvit/assessment-tax/errs"
 	"github.com/Atvit/assessment-tax/internals/models"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -15,8 +17,8 @@ var mockDBErr = errors.New("could not open database connection")
 func TestRepository_Get(t *testing.T) {
 	mockRow := models.DeductionConfig{
 		ID:        1,
-		Personal:  60000.00,
-		KReceipt:  70000.00,
+		Personal:  decimal.NewFromInt(60000),
+		KReceipt:  decimal.NewFromInt(70000),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -35,8 +37,8 @@ func TestRepository_Get(t *testing.T) {
 		assert.Nil(t, err)
 		assert.NotNil(t, result)
 		assert.Equal(t, 1, result.ID)
-		assert.Equal(t, 60000.00, result.Personal)
-		assert.Equal(t, 70000.00, result.KReceipt)
+		assert.Equal(t, "60000", result.Personal.String())
+		assert.Equal(t, "70000", result.KReceipt.String())
 	})
 
 	t.Run("error", func(t *testing.T) {
@@ -65,8 +67,8 @@ func TestRepository_Get(t *testing.T) {
 func TestRepository_UpdatePersonalDeduction(t *testing.T) {
 	mockRow := models.DeductionConfig{
 		ID:        1,
-		Personal:  60000.00,
-		KReceipt:  70000.00,
+		Personal:  decimal.NewFromInt(60000),
+		KReceipt:  decimal.NewFromInt(70000),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -78,21 +80,21 @@ func TestRepository_UpdatePersonalDeduction(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		mock.ExpectQuery(updatePersonalStmt).
-			WithArgs(60000.00, sqlmock.AnyArg(), 1).
+			WithArgs(decimal.NewFromInt(60000), sqlmock.AnyArg(), 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "personal", "kreceipt", "created_at", "updated_at"}).
 				AddRow(mockRow.ID, mockRow.Personal, mockRow.KReceipt, mockRow.CreatedAt, mockRow.UpdatedAt))
 
-		result, err := r.UpdatePersonalDeduction(1, 60000.00)
+		result, err := r.UpdatePersonalDeduction(1, decimal.NewFromInt(60000))
 
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
-		assert.Equal(t, 60000.00, result.Personal)
+		assert.Equal(t, "60000", result.Personal.String())
 	})
 
 	t.Run("error", func(t *testing.T) {
 		mock.ExpectQuery(updatePersonalStmt).WillReturnError(mockDBErr)
 
-		result, err := r.UpdatePersonalDeduction(1, 60000.00)
+		result, err := r.UpdatePersonalDeduction(1, decimal.NewFromInt(60000))
 
 		assert.Nil(t, result)
 		assert.Error(t, mockDBErr, err)
@@ -102,8 +104,8 @@ func TestRepository_UpdatePersonalDeduction(t *testing.T) {
 func TestRepository_UpdateKReceiptDeduction(t *testing.T) {
 	mockRow := models.DeductionConfig{
 		ID:        1,
-		Personal:  60000.00,
-		KReceipt:  70000.00,
+		Personal:  decimal.NewFromInt(60000),
+		KReceipt:  decimal.NewFromInt(70000),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -115,22 +117,311 @@ func TestRepository_UpdateKReceiptDeduction(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		mock.ExpectQuery(updateKReceiptStmt).
-			WithArgs(70000.00, sqlmock.AnyArg(), 1).
+			WithArgs(decimal.NewFromInt(70000), sqlmock.AnyArg(), 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "personal", "kreceipt", "created_at", "updated_at"}).
 				AddRow(1, mockRow.Personal, mockRow.KReceipt, mockRow.CreatedAt, mockRow.UpdatedAt))
 
-		result, err := r.UpdateKReceiptDeduction(1, 70000.00)
+		result, err := r.UpdateKReceiptDeduction(1, decimal.NewFromInt(70000))
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
-		assert.Equal(t, 70000.00, result.KReceipt)
+		assert.Equal(t, "70000", result.KReceipt.String())
 	})
 
 	t.Run("error", func(t *testing.T) {
 		mock.ExpectQuery(updateKReceiptStmt).WillReturnError(mockDBErr)
 
-		result, err := r.UpdateKReceiptDeduction(1, 70000.00)
+		result, err := r.UpdateKReceiptDeduction(1, decimal.NewFromInt(70000))
 
 		assert.Nil(t, result)
 		assert.Equal(t, mockDBErr, err)
 	})
 }
+
+func TestRepository_GetBrackets(t *testing.T) {
+	columns := []string{"id", "tax_year", "lower_bound", "upper_bound", "rate", "created_at", "updated_at"}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	r := NewRepository(db)
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(1, 2567, 0.0, 150000.0, 0.0, time.Now(), time.Now()).
+			AddRow(2, 2567, 150000.0, nil, 0.1, time.Now(), time.Now())
+		mock.ExpectQuery(getBracketsStmt).WithArgs(2567).WillReturnRows(rows)
+
+		result, err := r.GetBrackets(2567)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, "150000", result[0].Upper.String())
+		assert.Nil(t, result[1].Upper)
+		assert.Equal(t, "0.1", result[1].Rate.String())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mock.ExpectQuery(getBracketsStmt).WithArgs(2567).WillReturnError(mockDBErr)
+
+		result, err := r.GetBrackets(2567)
+
+		assert.Nil(t, result)
+		assert.Equal(t, mockDBErr, err)
+	})
+
+	t.Run("error scan rows", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(nil, nil, nil, nil, nil, nil, nil)
+		mock.ExpectQuery(getBracketsStmt).WithArgs(2567).WillReturnRows(rows)
+
+		result, err := r.GetBrackets(2567)
+
+		assert.Nil(t, result)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("error iterate rows", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(1, 2567, 0.0, 150000.0, 0.0, time.Now(), time.Now()).
+			AddRow(2, 2567, 150000.0, nil, 0.1, time.Now(), time.Now()).
+			RowError(1, mockDBErr)
+		mock.ExpectQuery(getBracketsStmt).WithArgs(2567).WillReturnRows(rows)
+
+		result, err := r.GetBrackets(2567)
+
+		assert.Nil(t, result)
+		assert.Equal(t, mockDBErr, err)
+	})
+}
+
+func TestRepository_CreateBrackets(t *testing.T) {
+	columns := []string{"id", "tax_year", "lower_bound", "upper_bound", "rate", "created_at", "updated_at"}
+	upper := decimal.NewFromInt(150000)
+	brackets := []models.TaxBracket{
+		{TaxYear: 2568, Lower: decimal.Zero, Upper: &upper, Rate: decimal.Zero},
+		{TaxYear: 2568, Lower: decimal.NewFromInt(150000), Rate: decimal.RequireFromString("0.1")},
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	r := NewRepository(db)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockBracketsStmt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(countBracketsStmt).WithArgs(2568).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(insertBracketStmt).
+			WithArgs(2568, decimal.Zero, &upper, decimal.Zero).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 2568, 0.0, upper, 0.0, time.Now(), time.Now()))
+		mock.ExpectQuery(insertBracketStmt).
+			WithArgs(2568, decimal.NewFromInt(150000), nil, decimal.RequireFromString("0.1")).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 2568, 150000.0, nil, 0.1, time.Now(), time.Now()))
+		mock.ExpectCommit()
+
+		result, err := r.CreateBrackets(2568, brackets)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already exist", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockBracketsStmt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(countBracketsStmt).WithArgs(2568).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectRollback()
+
+		result, err := r.CreateBrackets(2568, brackets)
+
+		assert.Nil(t, result)
+		assert.Equal(t, errs.ErrBracketsAlreadyExist, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lock error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockBracketsStmt).WillReturnError(mockDBErr)
+		mock.ExpectRollback()
+
+		result, err := r.CreateBrackets(2568, brackets)
+
+		assert.Nil(t, result)
+		assert.Equal(t, mockDBErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockBracketsStmt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(countBracketsStmt).WithArgs(2568).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(insertBracketStmt).WillReturnError(mockDBErr)
+		mock.ExpectRollback()
+
+		result, err := r.CreateBrackets(2568, brackets)
+
+		assert.Nil(t, result)
+		assert.Equal(t, mockDBErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_ReplaceBrackets(t *testing.T) {
+	columns := []string{"id", "tax_year", "lower_bound", "upper_bound", "rate", "created_at", "updated_at"}
+	brackets := []models.TaxBracket{{TaxYear: 2567, Lower: decimal.Zero, Rate: decimal.RequireFromString("0.1")}}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	r := NewRepository(db)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockBracketsStmt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(countBracketsStmt).WithArgs(2567).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectExec(deleteBracketsStmt).WithArgs(2567).WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectQuery(insertBracketStmt).
+			WithArgs(2567, decimal.Zero, nil, decimal.RequireFromString("0.1")).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(6, 2567, 0.0, nil, 0.1, time.Now(), time.Now()))
+		mock.ExpectCommit()
+
+		result, err := r.ReplaceBrackets(2567, brackets)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockBracketsStmt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(countBracketsStmt).WithArgs(2567).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		result, err := r.ReplaceBrackets(2567, brackets)
+
+		assert.Nil(t, result)
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockBracketsStmt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(countBracketsStmt).WithArgs(2567).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectExec(deleteBracketsStmt).WithArgs(2567).WillReturnError(mockDBErr)
+		mock.ExpectRollback()
+
+		result, err := r.ReplaceBrackets(2567, brackets)
+
+		assert.Nil(t, result)
+		assert.Equal(t, mockDBErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_DeleteBrackets(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	r := NewRepository(db)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(deleteBracketsStmt).WithArgs(2567).WillReturnResult(sqlmock.NewResult(0, 5))
+
+		assert.NoError(t, r.DeleteBrackets(2567))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectExec(deleteBracketsStmt).WithArgs(2566).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, sql.ErrNoRows, r.DeleteBrackets(2566))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mock.ExpectExec(deleteBracketsStmt).WithArgs(2567).WillReturnError(mockDBErr)
+
+		assert.Equal(t, mockDBErr, r.DeleteBrackets(2567))
+	})
+}
+
+func TestRepository_GetExchangeRate(t *testing.T) {
+	columns := []string{"id", "currency", "rate_date", "rate", "created_at", "updated_at"}
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	r := NewRepository(db)
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, "USD", date.AddDate(0, 0, -1), 36.5, time.Now(), time.Now())
+		mock.ExpectQuery(getRateStmt).WithArgs("USD", date).WillReturnRows(rows)
+
+		result, err := r.GetExchangeRate("USD", date)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "USD", result.Currency)
+		assert.Equal(t, "36.5", result.Rate.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery(getRateStmt).WithArgs("EUR", date).WillReturnRows(sqlmock.NewRows(columns))
+
+		result, err := r.GetExchangeRate("EUR", date)
+
+		assert.Nil(t, result)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mock.ExpectQuery(getRateStmt).WithArgs("USD", date).WillReturnError(mockDBErr)
+
+		result, err := r.GetExchangeRate("USD", date)
+
+		assert.Nil(t, result)
+		assert.Equal(t, mockDBErr, err)
+	})
+}
+
+func TestRepository_SaveExchangeRates(t *testing.T) {
+	columns := []string{"id", "currency", "rate_date", "rate", "created_at", "updated_at"}
+	date := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	rates := []models.ExchangeRate{
+		{Currency: "USD", Date: date, Rate: decimal.RequireFromString("36.5")},
+		{Currency: "EUR", Date: date, Rate: decimal.RequireFromString("39.2")},
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer db.Close()
+	r := NewRepository(db)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(upsertRateStmt).
+			WithArgs("USD", date, decimal.RequireFromString("36.5"), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "USD", date, 36.5, time.Now(), time.Now()))
+		mock.ExpectQuery(upsertRateStmt).
+			WithArgs("EUR", date, decimal.RequireFromString("39.2"), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "EUR", date, 39.2, time.Now(), time.Now()))
+		mock.ExpectCommit()
+
+		result, err := r.SaveExchangeRates(rates)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(upsertRateStmt).WillReturnError(mockDBErr)
+		mock.ExpectRollback()
+
+		result, err := r.SaveExchangeRates(rates)
+
+		assert.Nil(t, result)
+		assert.Equal(t, mockDBErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
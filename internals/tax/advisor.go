@@ -0,0 +1,125 @@
+package tax
+
+import (
+	"github.com/shopspring/decimal"
+	"slices"
+)
+
+const headroomProbeAmount = 1000000000000
+
+type Headroom struct {
+	AllowanceType string
+	Amount        decimal.Decimal
+}
+
+type Suggestion struct {
+	AllowanceType string
+	Amount        decimal.Decimal
+	TaxSaved      decimal.Decimal
+}
+
+type Advice struct {
+	Result          Result
+	TaxSavedPerBaht decimal.Decimal
+	Headroom        []Headroom
+	Suggestions     []Suggestion
+	TaxSaved        decimal.Decimal
+}
+
+// advise reports how much more each purchasable allowance can take and spends
+// the budget greedily on them, stopping once the income is no longer taxed.
+// Every figure comes from calculateCopy, so caps and groups behave as they do there.
+func advise(t *Tax, budget decimal.Decimal) (Advice, error) {
+	result, err := calculateWith(t, t.Allowances)
+	if err != nil {
+		return Advice{}, err
+	}
+
+	advice := Advice{
+		Result:          result,
+		TaxSavedPerBaht: result.MarginalRate,
+		TaxSaved:        decimal.Zero,
+	}
+	if result.Method == minimumMethod {
+		advice.TaxSavedPerBaht = decimal.Zero
+	}
+
+	for _, allowanceType := range allowanceTypes.purchasable() {
+		amount, err := getHeadroom(t, t.Allowances, allowanceType.Name)
+		if err != nil {
+			return Advice{}, err
+		}
+
+		advice.Headroom = append(advice.Headroom, Headroom{
+			AllowanceType: allowanceType.Name,
+			Amount:        amount,
+		})
+	}
+
+	ruleSet, _ := t.getRuleSet()
+	threshold := getTaxFreeThreshold(ruleSet.Brackets)
+
+	allowances := slices.Clone(t.Allowances)
+	current := result
+	remaining := budget
+	for _, allowanceType := range allowanceTypes.purchasable() {
+		taxable := current.TaxableIncome.Sub(threshold)
+		if !remaining.IsPositive() || !taxable.IsPositive() {
+			break
+		}
+
+		headroom, err := getHeadroom(t, allowances, allowanceType.Name)
+		if err != nil {
+			return Advice{}, err
+		}
+
+		amount := decimal.Min(headroom, remaining, taxable)
+		if !amount.IsPositive() {
+			continue
+		}
+
+		allowances = append(allowances, Allowance{AllowanceType: allowanceType.Name, Amount: amount})
+		next, err := calculateWith(t, allowances)
+		if err != nil {
+			return Advice{}, err
+		}
+
+		saved := getLiability(current).Sub(getLiability(next))
+		advice.Suggestions = append(advice.Suggestions, Suggestion{
+			AllowanceType: allowanceType.Name,
+			Amount:        amount,
+			TaxSaved:      saved,
+		})
+		advice.TaxSaved = advice.TaxSaved.Add(saved)
+
+		remaining = remaining.Sub(amount)
+		current = next
+	}
+
+	return advice, nil
+}
+
+func getHeadroom(t *Tax, allowances []Allowance, name string) (decimal.Decimal, error) {
+	probe := append(slices.Clone(allowances), Allowance{
+		AllowanceType: name,
+		Amount:        decimal.NewFromInt(headroomProbeAmount),
+	})
+
+	result, err := calculateWith(t, probe)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return result.Allowances[len(allowances)].Accepted, nil
+}
+
+func calculateWith(t *Tax, allowances []Allowance) (Result, error) {
+	tax := *t
+	tax.Allowances = slices.Clone(allowances)
+
+	return calculateCopy(&tax)
+}
+
+func getLiability(result Result) decimal.Decimal {
+	return result.Tax.Sub(result.Refund)
+}
@@ -0,0 +1,106 @@
+package tax
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAdvise(t *testing.T) {
+	t.Run("headroom and marginal rate", func(t *testing.T) {
+		advice, err := NewCalculator().Advise(Tax{Income: decimal.NewFromInt(1000000)}, decimal.Zero)
+
+		expected := map[string]string{
+			kReceipt:              "50000",
+			lifeInsurance:         "100000",
+			healthInsurance:       "25000",
+			parentHealthInsurance: "15000",
+			annuityInsurance:      "150000",
+			providentFund:         "150000",
+			rmf:                   "300000",
+			ssf:                   "200000",
+			thaiESG:               "300000",
+			donation:              "94000",
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, "101000", advice.Result.Tax.String())
+		assert.Equal(t, "0.15", advice.TaxSavedPerBaht.String())
+		assert.Len(t, advice.Headroom, len(expected))
+		for _, headroom := range advice.Headroom {
+			assert.Equal(t, expected[headroom.AllowanceType], headroom.Amount.String(), headroom.AllowanceType)
+		}
+		assert.Empty(t, advice.Suggestions)
+	})
+
+	t.Run("headroom after existing claims", func(t *testing.T) {
+		advice, err := NewCalculator().Advise(Tax{
+			Income: decimal.NewFromInt(1000000),
+			Allowances: []Allowance{
+				{AllowanceType: lifeInsurance, Amount: decimal.NewFromInt(90000)},
+				{AllowanceType: providentFund, Amount: decimal.NewFromInt(150000)},
+				{AllowanceType: rmf, Amount: decimal.NewFromInt(300000)},
+			},
+		}, decimal.Zero)
+
+		headroom := map[string]string{}
+		for _, h := range advice.Headroom {
+			headroom[h.AllowanceType] = h.Amount.String()
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10000", headroom[healthInsurance])
+		assert.Equal(t, "50000", headroom[ssf])
+		assert.Equal(t, "300000", headroom[thaiESG])
+	})
+
+	t.Run("budget mix", func(t *testing.T) {
+		advice, err := NewCalculator().Advise(Tax{Income: decimal.NewFromInt(1000000)}, decimal.NewFromInt(100000))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Suggestion{
+			{AllowanceType: kReceipt, Amount: decimal.NewFromInt(50000), TaxSaved: decimal.NewFromInt(7500)},
+			{AllowanceType: lifeInsurance, Amount: decimal.NewFromInt(50000), TaxSaved: decimal.NewFromInt(7500)},
+		}, normalizeSuggestions(advice.Suggestions))
+		assert.Equal(t, "15000", advice.TaxSaved.String())
+	})
+
+	t.Run("budget stops at tax free income", func(t *testing.T) {
+		advice, err := NewCalculator().Advise(Tax{Income: decimal.NewFromInt(1000000)}, decimal.NewFromInt(2000000))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Suggestion{
+			{AllowanceType: kReceipt, Amount: decimal.NewFromInt(50000), TaxSaved: decimal.NewFromInt(7500)},
+			{AllowanceType: lifeInsurance, Amount: decimal.NewFromInt(100000), TaxSaved: decimal.NewFromInt(15000)},
+			{AllowanceType: parentHealthInsurance, Amount: decimal.NewFromInt(15000), TaxSaved: decimal.NewFromInt(2250)},
+			{AllowanceType: annuityInsurance, Amount: decimal.NewFromInt(150000), TaxSaved: decimal.NewFromInt(22500)},
+			{AllowanceType: providentFund, Amount: decimal.NewFromInt(150000), TaxSaved: decimal.NewFromInt(21250)},
+			{AllowanceType: rmf, Amount: decimal.NewFromInt(200000), TaxSaved: decimal.NewFromInt(20000)},
+			{AllowanceType: thaiESG, Amount: decimal.NewFromInt(125000), TaxSaved: decimal.NewFromInt(12500)},
+		}, normalizeSuggestions(advice.Suggestions))
+		assert.Equal(t, "101000", advice.TaxSaved.String())
+	})
+
+	t.Run("minimum tax method", func(t *testing.T) {
+		advice, err := NewCalculator().Advise(Tax{
+			Incomes: []Income{{Category: businessIncome, Amount: decimal.NewFromInt(2000000), Expense: decimal.NewFromInt(1990000)}},
+		}, decimal.Zero)
+
+		assert.NoError(t, err)
+		assert.Equal(t, minimumMethod, advice.Result.Method)
+		assert.Equal(t, "0", advice.TaxSavedPerBaht.String())
+	})
+}
+
+func normalizeSuggestions(suggestions []Suggestion) []Suggestion {
+	normalized := make([]Suggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		normalized = append(normalized, Suggestion{
+			AllowanceType: s.AllowanceType,
+			Amount:        decimal.RequireFromString(s.Amount.String()),
+			TaxSaved:      decimal.RequireFromString(s.TaxSaved.String()),
+		})
+	}
+
+	return normalized
+}
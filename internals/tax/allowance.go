@@ -0,0 +1,550 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+	"sort"
+)
+
+type AllowanceContext struct {
+	RuleSet      RuleSet
+	Setting      AllowanceSetting
+	Income       decimal.Decimal
+	SpouseIncome decimal.Decimal
+	Expenses     decimal.Decimal
+	NetIncome    decimal.Decimal
+	Residency    Residency
+}
+
+// AllowanceType describes one kind of deduction. Automatic types are granted to
+// every filer and cannot be requested by callers. Default is the amount granted
+// when none is given. IncomeRate caps a claim at a share of income and Cap at a
+// fixed amount; a nil cap means the amount is not limited. MaxClaims limits how
+// many claims of the type are accepted, each capped on its own as they stand
+// for different people; claims of other types are merged before the caps apply.
+// Accept replaces the Default and caps when amounts depend on the other claims
+// of the type.
+// AfterDeductions types are accepted in a second pass, once NetIncome is known.
+// Purchasable types are bought with money and are suggested by the advisor.
+// Shared types are claimed once for a couple filing jointly; the claims of the
+// other types are accepted, capped and grouped for each spouse on their own.
+// NonResident says whether non-residents claim the type in full, prorated by
+// their days in Thailand, or not at all.
+type AllowanceType struct {
+	Name            string
+	Automatic       bool
+	AfterDeductions bool
+	Purchasable     bool
+	Shared          bool
+	NonResident     string
+	Default         func(ctx AllowanceContext) decimal.Decimal
+	IncomeRate      func(ctx AllowanceContext) decimal.Decimal
+	Cap             func(ctx AllowanceContext) decimal.Decimal
+	MaxClaims       func(ctx AllowanceContext) int
+	Accept          func(claims []Allowance, ctx AllowanceContext) []AcceptedAllowance
+	Validate        func(allowance Allowance, ctx AllowanceContext) error
+}
+
+// CapGroup limits the combined accepted amount of several allowance types.
+// Claims are filled in request order until the cap is used up.
+type CapGroup struct {
+	Name  string
+	Types []string
+	Cap   func(ctx AllowanceContext) decimal.Decimal
+}
+
+type AcceptedAllowance struct {
+	Allowance
+	Accepted  decimal.Decimal
+	Reduction decimal.Decimal
+	LimitedBy string
+}
+
+type allowanceRegistry struct {
+	types  map[string]AllowanceType
+	names  []string
+	groups []CapGroup
+}
+
+// allowanceTypes is built once at package initialisation and only read after
+// that, so calculations can share it between goroutines.
+var allowanceTypes = newAllowanceRegistry(
+	AllowanceType{
+		Name:        personal,
+		Automatic:   true,
+		NonResident: prorateAllowance,
+		Default: func(ctx AllowanceContext) decimal.Decimal {
+			return settingOrDefault(ctx.Setting.Personal, ctx.RuleSet.DefaultPersonalAllowance)
+		},
+	},
+	AllowanceType{
+		Name:            donation,
+		Purchasable:     true,
+		AfterDeductions: true,
+		Shared:          true,
+		Accept:          acceptDonations,
+		Validate: func(allowance Allowance, ctx AllowanceContext) error {
+			if allowance.Category == "" {
+				return nil
+			}
+			if ok := utils.Oneof(allowance.Category, generalDonation, educationDonation, hospitalDonation, politicalDonation); !ok {
+				return errs.ErrIncorrectDonationCategory
+			}
+			return nil
+		},
+	},
+	AllowanceType{
+		Name:        kReceipt,
+		Purchasable: true,
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return settingOrDefault(ctx.Setting.KReceipt, ctx.RuleSet.DefaultKReceiptAllowance)
+		},
+	},
+	AllowanceType{
+		Name:        spouse,
+		Shared:      true,
+		NonResident: disallowAllowance,
+		Default: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.SpouseAllowance
+		},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.SpouseAllowance
+		},
+		MaxClaims: func(ctx AllowanceContext) int {
+			return 1
+		},
+		Validate: func(allowance Allowance, ctx AllowanceContext) error {
+			if allowance.Income.IsPositive() {
+				return errs.ErrSpouseHasIncome
+			}
+			return nil
+		},
+	},
+	AllowanceType{
+		Name:        child,
+		Shared:      true,
+		NonResident: disallowAllowance,
+		Accept:      acceptChildren,
+		Validate: func(allowance Allowance, ctx AllowanceContext) error {
+			if allowance.BirthYear <= 0 {
+				return errs.ErrChildBirthYearRequired
+			}
+			return nil
+		},
+	},
+	AllowanceType{
+		Name:        parent,
+		NonResident: disallowAllowance,
+		Default: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.ParentAllowance
+		},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.ParentAllowance
+		},
+		MaxClaims: func(ctx AllowanceContext) int {
+			return ctx.RuleSet.MaxParents
+		},
+		Validate: func(allowance Allowance, ctx AllowanceContext) error {
+			if allowance.Age < ctx.RuleSet.ParentMinAge {
+				return errs.ErrParentUnderAge
+			}
+			if allowance.Income.GreaterThan(ctx.RuleSet.ParentMaxIncome) {
+				return errs.ErrParentIncomeExceeded
+			}
+			return nil
+		},
+	},
+	AllowanceType{
+		Name:        lifeInsurance,
+		Purchasable: true,
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.LifeInsuranceCap
+		},
+	},
+	AllowanceType{
+		Name:        healthInsurance,
+		Purchasable: true,
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.HealthInsuranceCap
+		},
+	},
+	AllowanceType{
+		Name:        parentHealthInsurance,
+		Purchasable: true,
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.ParentHealthInsuranceCap
+		},
+	},
+	AllowanceType{
+		Name:        annuityInsurance,
+		Purchasable: true,
+		IncomeRate: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.AnnuityIncomeRate
+		},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.AnnuityCap
+		},
+	},
+	AllowanceType{
+		Name:        providentFund,
+		Purchasable: true,
+		IncomeRate: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.ProvidentFundIncomeRate
+		},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.ProvidentFundCap
+		},
+	},
+	AllowanceType{
+		Name:        rmf,
+		Purchasable: true,
+		IncomeRate: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.RMFIncomeRate
+		},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.RMFCap
+		},
+	},
+	AllowanceType{
+		Name:        ssf,
+		Purchasable: true,
+		IncomeRate: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.SSFIncomeRate
+		},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.SSFCap
+		},
+	},
+	AllowanceType{
+		Name:        thaiESG,
+		Purchasable: true,
+		IncomeRate: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.ThaiESGIncomeRate
+		},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.ThaiESGCap
+		},
+	},
+).withCapGroups(
+	CapGroup{
+		Name:  "life-health-insurance",
+		Types: []string{lifeInsurance, healthInsurance},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.LifeHealthInsuranceCap
+		},
+	},
+	CapGroup{
+		Name:  "retirement",
+		Types: []string{providentFund, rmf, ssf, annuityInsurance},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return ctx.RuleSet.RetirementCap
+		},
+	},
+)
+
+func newAllowanceRegistry(types ...AllowanceType) *allowanceRegistry {
+	r := &allowanceRegistry{types: map[string]AllowanceType{}}
+	for _, t := range types {
+		r.register(t)
+	}
+
+	return r
+}
+
+func (r *allowanceRegistry) withCapGroups(groups ...CapGroup) *allowanceRegistry {
+	r.groups = append(r.groups, groups...)
+	return r
+}
+
+func (r *allowanceRegistry) register(t AllowanceType) {
+	if _, ok := r.types[t.Name]; !ok {
+		r.names = append(r.names, t.Name)
+	}
+
+	r.types[t.Name] = t
+}
+
+func (r *allowanceRegistry) get(name string) (AllowanceType, bool) {
+	t, ok := r.types[name]
+	return t, ok
+}
+
+func (r *allowanceRegistry) automatic() []AllowanceType {
+	var types []AllowanceType
+	for _, name := range r.names {
+		if t := r.types[name]; t.Automatic {
+			types = append(types, t)
+		}
+	}
+
+	return types
+}
+
+func (r *allowanceRegistry) purchasable() []AllowanceType {
+	var types []AllowanceType
+	for _, name := range r.names {
+		if t := r.types[name]; t.Purchasable && !t.AfterDeductions {
+			types = append(types, t)
+		}
+	}
+	for _, name := range r.names {
+		if t := r.types[name]; t.Purchasable && t.AfterDeductions {
+			types = append(types, t)
+		}
+	}
+
+	return types
+}
+
+func (r *allowanceRegistry) requestable() []string {
+	var names []string
+	for _, name := range r.names {
+		if !r.types[name].Automatic {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func (t AllowanceType) validate(allowance Allowance, ctx AllowanceContext) error {
+	if ok := utils.Gte(allowance.Amount, decimal.Zero); !ok {
+		return errs.ErrValueMustBePositive
+	}
+
+	if ok := utils.Gte(allowance.Income, decimal.Zero); !ok {
+		return errs.ErrValueMustBePositive
+	}
+
+	if t.Validate != nil {
+		return t.Validate(allowance, ctx)
+	}
+
+	return nil
+}
+
+func (t AllowanceType) request(allowance Allowance, ctx AllowanceContext) AcceptedAllowance {
+	accepted := AcceptedAllowance{Allowance: allowance, Accepted: allowance.Amount}
+	if accepted.Accepted.IsZero() && t.Default != nil {
+		accepted.Accepted = t.Default(ctx)
+	}
+
+	return accepted
+}
+
+func (t AllowanceType) accept(allowance Allowance, ctx AllowanceContext) AcceptedAllowance {
+	accepted := t.request(allowance, ctx)
+	t.applyCaps(&accepted, ctx)
+
+	return accepted
+}
+
+func (t AllowanceType) applyCaps(accepted *AcceptedAllowance, ctx AllowanceContext) {
+	if t.IncomeRate != nil {
+		accepted.limit(ctx.Income.Mul(t.IncomeRate(ctx)), t.Name+"-income-rate")
+	}
+
+	if t.Cap != nil {
+		accepted.limit(t.Cap(ctx), t.Name+"-max")
+	}
+}
+
+// acceptMerged caps the total of the claims and shares the accepted total out
+// in request order, so splitting a claim into several does not raise the cap.
+func (t AllowanceType) acceptMerged(claims []Allowance, ctx AllowanceContext) []AcceptedAllowance {
+	accepted := make([]AcceptedAllowance, len(claims))
+	merged := AcceptedAllowance{Allowance: Allowance{AllowanceType: t.Name}}
+	for i, claim := range claims {
+		accepted[i] = t.request(claim, ctx)
+		merged.Accepted = merged.Accepted.Add(accepted[i].Accepted)
+	}
+
+	t.applyCaps(&merged, ctx)
+
+	remaining := merged.Accepted
+	for i := range accepted {
+		accepted[i].limit(remaining, merged.LimitedBy)
+		remaining = remaining.Sub(accepted[i].Accepted)
+	}
+
+	return accepted
+}
+
+func (t AllowanceType) acceptAll(claims []Allowance, ctx AllowanceContext) []AcceptedAllowance {
+	var accepted []AcceptedAllowance
+	switch {
+	case t.Accept != nil:
+		accepted = t.Accept(claims, ctx)
+	case t.MaxClaims == nil:
+		accepted = t.acceptMerged(claims, ctx)
+	default:
+		accepted = make([]AcceptedAllowance, len(claims))
+		for i, claim := range claims {
+			accepted[i] = t.accept(claim, ctx)
+		}
+	}
+
+	if t.MaxClaims != nil {
+		for i := t.MaxClaims(ctx); i < len(accepted); i++ {
+			accepted[i].limit(decimal.Zero, t.Name+"-max-claims")
+		}
+	}
+
+	for i := range accepted {
+		ctx.Residency.apply(&accepted[i], t, ctx.RuleSet.Precision)
+	}
+
+	return accepted
+}
+
+func (a *AcceptedAllowance) limit(amount decimal.Decimal, rule string) {
+	if a.Accepted.GreaterThan(amount) {
+		a.Reduction = a.Reduction.Add(a.Accepted.Sub(amount))
+		a.Accepted = amount
+		a.LimitedBy = rule
+	}
+}
+
+type claimKey struct {
+	allowanceType string
+	spouse        bool
+}
+
+func acceptAllowances(allowances []Allowance, ctx AllowanceContext) []AcceptedAllowance {
+	accepted := make([]AcceptedAllowance, len(allowances))
+	indexesByKey := map[claimKey][]int{}
+	for i, allowance := range allowances {
+		key := claimKey{allowanceType: allowance.AllowanceType, spouse: allowance.Spouse}
+		if allowanceType, ok := allowanceTypes.get(allowance.AllowanceType); ok && allowanceType.Shared {
+			key.spouse = false
+		}
+		indexesByKey[key] = append(indexesByKey[key], i)
+	}
+
+	acceptPass(allowances, accepted, indexesByKey, false, ctx)
+	for _, group := range allowanceTypes.groups {
+		group.apply(accepted, ctx)
+	}
+
+	ctx.NetIncome = ctx.Income.Sub(ctx.Expenses).Sub(getDeductAmount(accepted))
+	acceptPass(allowances, accepted, indexesByKey, true, ctx)
+	for _, group := range allowanceTypes.groups {
+		group.apply(accepted, ctx)
+	}
+
+	return accepted
+}
+
+func acceptPass(allowances []Allowance, accepted []AcceptedAllowance, indexesByKey map[claimKey][]int, afterDeductions bool, ctx AllowanceContext) {
+	for _, name := range allowanceTypes.names {
+		allowanceType, _ := allowanceTypes.get(name)
+		if allowanceType.AfterDeductions != afterDeductions {
+			continue
+		}
+
+		for _, spouse := range []bool{false, true} {
+			indexes, ok := indexesByKey[claimKey{allowanceType: name, spouse: spouse}]
+			if !ok {
+				continue
+			}
+
+			claims := make([]Allowance, len(indexes))
+			for j, i := range indexes {
+				claims[j] = allowances[i]
+			}
+
+			ownerCtx := ctx
+			if !allowanceType.Shared {
+				ownerCtx = ctx.forSpouse(spouse)
+			}
+
+			for j, allowance := range allowanceType.acceptAll(claims, ownerCtx) {
+				accepted[indexes[j]] = allowance
+			}
+		}
+	}
+}
+
+// forSpouse returns the context for the claims of the filer or of the spouse
+// in a joint filing, whose income-rate caps use only their own income.
+func (ctx AllowanceContext) forSpouse(spouse bool) AllowanceContext {
+	if spouse {
+		ctx.Income = ctx.SpouseIncome
+	} else {
+		ctx.Income = ctx.Income.Sub(ctx.SpouseIncome)
+	}
+
+	return ctx
+}
+
+// apply caps the group's claims of the filer and of the spouse separately.
+func (g CapGroup) apply(accepted []AcceptedAllowance, ctx AllowanceContext) {
+	remaining := map[bool]decimal.Decimal{false: g.Cap(ctx), true: g.Cap(ctx)}
+	for i, allowance := range accepted {
+		if ok := utils.Oneof(allowance.AllowanceType, g.Types...); !ok {
+			continue
+		}
+
+		accepted[i].limit(remaining[allowance.Spouse], g.Name)
+		remaining[allowance.Spouse] = remaining[allowance.Spouse].Sub(accepted[i].Accepted)
+	}
+}
+
+func acceptChildren(claims []Allowance, ctx AllowanceContext) []AcceptedAllowance {
+	order := make([]int, len(claims))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return claims[order[a]].BirthYear < claims[order[b]].BirthYear
+	})
+
+	accepted := make([]AcceptedAllowance, len(claims))
+	for rank, i := range order {
+		allowance := ctx.RuleSet.ChildAllowance
+		if rank > 0 && claims[i].BirthYear >= ctx.RuleSet.LaterChildBirthYear {
+			allowance = ctx.RuleSet.LaterChildAllowance
+		}
+
+		accepted[i] = AcceptedAllowance{Allowance: claims[i], Accepted: claims[i].Amount}
+		if accepted[i].Accepted.IsZero() {
+			accepted[i].Accepted = allowance
+		}
+		accepted[i].limit(allowance, child+"-max")
+	}
+
+	return accepted
+}
+
+func acceptDonations(claims []Allowance, ctx AllowanceContext) []AcceptedAllowance {
+	remaining := decimal.Max(ctx.NetIncome.Mul(ctx.RuleSet.DonationNetIncomeRate), decimal.Zero)
+	remainingPolitical := ctx.RuleSet.PoliticalDonationCap
+
+	accepted := make([]AcceptedAllowance, len(claims))
+	for i, claim := range claims {
+		accepted[i] = AcceptedAllowance{Allowance: claim, Accepted: claim.Amount}
+
+		switch claim.Category {
+		case politicalDonation:
+			accepted[i].limit(remainingPolitical, donation+"-political-max")
+			remainingPolitical = remainingPolitical.Sub(accepted[i].Accepted)
+		case educationDonation, hospitalDonation:
+			accepted[i].Accepted = claim.Amount.Mul(ctx.RuleSet.DoubleDonationMultiplier)
+			fallthrough
+		default:
+			accepted[i].limit(remaining, donation+"-net-income-rate")
+			remaining = remaining.Sub(accepted[i].Accepted)
+		}
+	}
+
+	return accepted
+}
+
+func settingOrDefault(setting, defaultValue decimal.Decimal) decimal.Decimal {
+	if setting.IsZero() {
+		return defaultValue
+	}
+
+	return setting
+}
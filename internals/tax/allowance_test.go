@@ -0,0 +1,478 @@
+package tax
+
+import (
+	"errors"
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAllowanceType_Accept(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+
+	tests := []struct {
+		name          string
+		allowanceType string
+		amount        decimal.Decimal
+		setting       AllowanceSetting
+		expected      string
+	}{
+		{"personal default", personal, decimal.Zero, AllowanceSetting{}, "60000"},
+		{"personal from setting", personal, decimal.Zero, AllowanceSetting{Personal: decimal.NewFromInt(70000)}, "70000"},
+		{"k-receipt default cap", kReceipt, decimal.NewFromInt(70000), AllowanceSetting{}, "50000"},
+		{"k-receipt cap from setting", kReceipt, decimal.NewFromInt(70000), AllowanceSetting{KReceipt: decimal.NewFromInt(60000)}, "60000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowanceType, ok := allowanceTypes.get(tt.allowanceType)
+			ctx := AllowanceContext{RuleSet: ruleSet, Setting: tt.setting}
+
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, allowanceType.accept(Allowance{AllowanceType: tt.allowanceType, Amount: tt.amount}, ctx).Accepted.String())
+		})
+	}
+}
+
+func TestAllowanceRegistry(t *testing.T) {
+	t.Run("requestable and automatic types", func(t *testing.T) {
+		assert.Equal(t, []string{donation, kReceipt, spouse, child, parent, lifeInsurance, healthInsurance, parentHealthInsurance, annuityInsurance, providentFund, rmf, ssf, thaiESG}, allowanceTypes.requestable())
+		assert.Len(t, allowanceTypes.automatic(), 1)
+		assert.Equal(t, personal, allowanceTypes.automatic()[0].Name)
+	})
+
+	t.Run("register new allowance type", func(t *testing.T) {
+		original := allowanceTypes
+		defer func() { allowanceTypes = original }()
+
+		allowanceTypes = newAllowanceRegistry()
+		for _, name := range original.names {
+			allowanceTypes.register(original.types[name])
+		}
+
+		errTooSmall := errors.New("amount too small")
+		allowanceTypes.register(AllowanceType{
+			Name: "education",
+			Cap: func(ctx AllowanceContext) decimal.Decimal {
+				return decimal.NewFromInt(20000)
+			},
+			Validate: func(allowance Allowance, ctx AllowanceContext) error {
+				if allowance.Amount.LessThan(decimal.NewFromInt(100)) {
+					return errTooSmall
+				}
+				return nil
+			},
+		})
+
+		assert.Equal(t, []string{donation, kReceipt, spouse, child, parent, lifeInsurance, healthInsurance, parentHealthInsurance, annuityInsurance, providentFund, rmf, ssf, thaiESG, "education"}, allowanceTypes.requestable())
+
+		result, err := NewCalculator().Calculate(Tax{
+			Income:     decimal.NewFromInt(500000),
+			Allowances: []Allowance{{AllowanceType: "education", Amount: decimal.NewFromInt(50000)}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "27000", result.Tax.String())
+
+		_, err = NewCalculator().Calculate(Tax{
+			Income:     decimal.NewFromInt(500000),
+			Allowances: []Allowance{{AllowanceType: "education", Amount: decimal.NewFromInt(10)}},
+		})
+		assert.Equal(t, errTooSmall, err)
+	})
+
+	t.Run("negative amount", func(t *testing.T) {
+		allowanceType, _ := allowanceTypes.get(donation)
+
+		assert.Equal(t, errs.ErrValueMustBePositive, allowanceType.validate(Allowance{AllowanceType: donation, Amount: decimal.NewFromInt(-1)}, AllowanceContext{}))
+	})
+}
+
+func TestValidateAllowanceRequest(t *testing.T) {
+	validate := utils.NewValidator()
+	registerValidations(validate)
+
+	tests := []struct {
+		name        string
+		req         AllowanceRequest
+		expectedErr bool
+	}{
+		{"donation", AllowanceRequest{AllowanceType: donation}, false},
+		{"k-receipt", AllowanceRequest{AllowanceType: kReceipt}, false},
+		{"empty allowance type", AllowanceRequest{}, false},
+		{"automatic allowance type", AllowanceRequest{AllowanceType: personal}, true},
+		{"unknown allowance type", AllowanceRequest{AllowanceType: "shop"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.req)
+
+			assert.Equal(t, tt.expectedErr, err != nil)
+		})
+	}
+}
+
+func TestAcceptAllowances(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+
+	tests := []struct {
+		name       string
+		income     int64
+		allowances []Allowance
+		expected   []string
+	}{
+		{
+			name: "later children born from 2561",
+			allowances: []Allowance{
+				{AllowanceType: child, BirthYear: 2560},
+				{AllowanceType: child, BirthYear: 2562},
+				{AllowanceType: child, BirthYear: 2563},
+			},
+			expected: []string{"30000", "60000", "60000"},
+		},
+		{
+			name: "children born before 2561",
+			allowances: []Allowance{
+				{AllowanceType: child, BirthYear: 2555},
+				{AllowanceType: child, BirthYear: 2558},
+			},
+			expected: []string{"30000", "30000"},
+		},
+		{
+			name: "children ordered by birth year",
+			allowances: []Allowance{
+				{AllowanceType: child, BirthYear: 2563},
+				{AllowanceType: child, BirthYear: 2559},
+			},
+			expected: []string{"60000", "30000"},
+		},
+		{
+			name: "twins",
+			allowances: []Allowance{
+				{AllowanceType: child, BirthYear: 2562},
+				{AllowanceType: child, BirthYear: 2562},
+			},
+			expected: []string{"30000", "60000"},
+		},
+		{
+			name: "parents capped per person and in count",
+			allowances: []Allowance{
+				{AllowanceType: parent, Age: 65},
+				{AllowanceType: parent, Age: 70, Amount: decimal.NewFromInt(50000)},
+				{AllowanceType: parent, Age: 61, Amount: decimal.NewFromInt(20000)},
+				{AllowanceType: parent, Age: 80},
+				{AllowanceType: parent, Age: 75},
+			},
+			expected: []string{"30000", "30000", "20000", "30000", "0"},
+		},
+		{
+			name: "single spouse",
+			allowances: []Allowance{
+				{AllowanceType: spouse},
+				{AllowanceType: spouse},
+			},
+			expected: []string{"60000", "0"},
+		},
+		{
+			name:   "mixed types keep request order",
+			income: 500000,
+			allowances: []Allowance{
+				{AllowanceType: donation, Amount: decimal.NewFromInt(20000)},
+				{AllowanceType: child, BirthYear: 2565},
+				{AllowanceType: spouse},
+			},
+			expected: []string{"20000", "30000", "60000"},
+		},
+		{
+			name: "insurance caps",
+			allowances: []Allowance{
+				{AllowanceType: lifeInsurance, Amount: decimal.NewFromInt(120000)},
+				{AllowanceType: healthInsurance, Amount: decimal.NewFromInt(30000)},
+				{AllowanceType: parentHealthInsurance, Amount: decimal.NewFromInt(20000)},
+			},
+			expected: []string{"100000", "0", "15000"},
+		},
+		{
+			name: "life and health insurance share a ceiling",
+			allowances: []Allowance{
+				{AllowanceType: healthInsurance, Amount: decimal.NewFromInt(20000)},
+				{AllowanceType: lifeInsurance, Amount: decimal.NewFromInt(90000)},
+			},
+			expected: []string{"20000", "80000"},
+		},
+		{
+			name:   "annuity capped at percentage of income",
+			income: 1000000,
+			allowances: []Allowance{
+				{AllowanceType: annuityInsurance, Amount: decimal.NewFromInt(180000)},
+			},
+			expected: []string{"150000"},
+		},
+		{
+			name:   "annuity capped at fixed amount",
+			income: 2000000,
+			allowances: []Allowance{
+				{AllowanceType: annuityInsurance, Amount: decimal.NewFromInt(250000)},
+			},
+			expected: []string{"200000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := AllowanceContext{RuleSet: ruleSet, Income: decimal.NewFromInt(tt.income)}
+			accepted := acceptAllowances(tt.allowances, ctx)
+
+			assert.Len(t, accepted, len(tt.expected))
+			for i, allowance := range accepted {
+				assert.Equal(t, tt.allowances[i], allowance.Allowance)
+				assert.Equal(t, tt.expected[i], allowance.Accepted.String())
+			}
+		})
+	}
+}
+
+func TestAcceptDonations(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+	ctx := AllowanceContext{RuleSet: ruleSet, NetIncome: decimal.NewFromInt(400000)}
+
+	tests := []struct {
+		name              string
+		claims            []Allowance
+		expected          []string
+		expectedLimitedBy []string
+	}{
+		{
+			name:              "general donation below net income rate",
+			claims:            []Allowance{{AllowanceType: donation, Amount: decimal.NewFromInt(30000)}},
+			expected:          []string{"30000"},
+			expectedLimitedBy: []string{""},
+		},
+		{
+			name:              "general donation above net income rate",
+			claims:            []Allowance{{AllowanceType: donation, Amount: decimal.NewFromInt(50000), Category: generalDonation}},
+			expected:          []string{"40000"},
+			expectedLimitedBy: []string{"donation-net-income-rate"},
+		},
+		{
+			name: "education and hospital donations count double",
+			claims: []Allowance{
+				{AllowanceType: donation, Amount: decimal.NewFromInt(10000), Category: educationDonation},
+				{AllowanceType: donation, Amount: decimal.NewFromInt(5000), Category: hospitalDonation},
+			},
+			expected:          []string{"20000", "10000"},
+			expectedLimitedBy: []string{"", ""},
+		},
+		{
+			name: "net income rate shared across categories",
+			claims: []Allowance{
+				{AllowanceType: donation, Amount: decimal.NewFromInt(15000), Category: educationDonation},
+				{AllowanceType: donation, Amount: decimal.NewFromInt(20000)},
+			},
+			expected:          []string{"30000", "10000"},
+			expectedLimitedBy: []string{"", "donation-net-income-rate"},
+		},
+		{
+			name: "political donations capped separately",
+			claims: []Allowance{
+				{AllowanceType: donation, Amount: decimal.NewFromInt(40000)},
+				{AllowanceType: donation, Amount: decimal.NewFromInt(6000), Category: politicalDonation},
+				{AllowanceType: donation, Amount: decimal.NewFromInt(6000), Category: politicalDonation},
+			},
+			expected:          []string{"40000", "6000", "4000"},
+			expectedLimitedBy: []string{"", "", "donation-political-max"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted := acceptDonations(tt.claims, ctx)
+
+			for i, allowance := range accepted {
+				assert.Equal(t, tt.expected[i], allowance.Accepted.String())
+				assert.Equal(t, tt.expectedLimitedBy[i], allowance.LimitedBy)
+			}
+		})
+	}
+
+	t.Run("negative net income", func(t *testing.T) {
+		ctx := AllowanceContext{RuleSet: ruleSet, NetIncome: decimal.NewFromInt(-1000)}
+		accepted := acceptDonations([]Allowance{{AllowanceType: donation, Amount: decimal.NewFromInt(1000)}}, ctx)
+
+		assert.Equal(t, "0", accepted[0].Accepted.String())
+	})
+}
+
+func TestAcceptAllowances_Limits(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+
+	type expected struct {
+		accepted  string
+		reduction string
+		limitedBy string
+	}
+
+	tests := []struct {
+		name       string
+		income     int64
+		allowances []Allowance
+		expected   []expected
+	}{
+		{
+			name:       "provident fund income rate",
+			income:     1000000,
+			allowances: []Allowance{{AllowanceType: providentFund, Amount: decimal.NewFromInt(200000)}},
+			expected:   []expected{{"150000", "50000", "provident-fund-income-rate"}},
+		},
+		{
+			name:       "rmf income rate",
+			income:     1000000,
+			allowances: []Allowance{{AllowanceType: rmf, Amount: decimal.NewFromInt(400000)}},
+			expected:   []expected{{"300000", "100000", "rmf-income-rate"}},
+		},
+		{
+			name:       "ssf fixed cap",
+			income:     2000000,
+			allowances: []Allowance{{AllowanceType: ssf, Amount: decimal.NewFromInt(250000)}},
+			expected:   []expected{{"200000", "50000", "ssf-max"}},
+		},
+		{
+			name:       "thai esg fixed cap",
+			income:     2000000,
+			allowances: []Allowance{{AllowanceType: thaiESG, Amount: decimal.NewFromInt(350000)}},
+			expected:   []expected{{"300000", "50000", "thai-esg-max"}},
+		},
+		{
+			name:   "retirement group cap",
+			income: 2000000,
+			allowances: []Allowance{
+				{AllowanceType: providentFund, Amount: decimal.NewFromInt(300000)},
+				{AllowanceType: rmf, Amount: decimal.NewFromInt(300000)},
+			},
+			expected: []expected{{"300000", "0", ""}, {"200000", "100000", "retirement"}},
+		},
+		{
+			name:   "retirement group includes annuity",
+			income: 2000000,
+			allowances: []Allowance{
+				{AllowanceType: providentFund, Amount: decimal.NewFromInt(300000)},
+				{AllowanceType: ssf, Amount: decimal.NewFromInt(100000)},
+				{AllowanceType: annuityInsurance, Amount: decimal.NewFromInt(250000)},
+			},
+			expected: []expected{{"300000", "0", ""}, {"100000", "0", ""}, {"100000", "150000", "retirement"}},
+		},
+		{
+			name:   "thai esg outside retirement group",
+			income: 2000000,
+			allowances: []Allowance{
+				{AllowanceType: providentFund, Amount: decimal.NewFromInt(300000)},
+				{AllowanceType: rmf, Amount: decimal.NewFromInt(200000)},
+				{AllowanceType: thaiESG, Amount: decimal.NewFromInt(300000)},
+			},
+			expected: []expected{{"300000", "0", ""}, {"200000", "0", ""}, {"300000", "0", ""}},
+		},
+		{
+			name: "duplicate k-receipts merged before cap",
+			allowances: []Allowance{
+				{AllowanceType: kReceipt, Amount: decimal.NewFromInt(30000)},
+				{AllowanceType: kReceipt, Amount: decimal.NewFromInt(30000)},
+			},
+			expected: []expected{{"30000", "0", ""}, {"20000", "10000", "k-receipt-max"}},
+		},
+		{
+			name:   "duplicate rmf merged before income rate",
+			income: 1000000,
+			allowances: []Allowance{
+				{AllowanceType: rmf, Amount: decimal.NewFromInt(200000)},
+				{AllowanceType: ssf, Amount: decimal.NewFromInt(50000)},
+				{AllowanceType: rmf, Amount: decimal.NewFromInt(200000)},
+			},
+			expected: []expected{{"200000", "0", ""}, {"50000", "0", ""}, {"100000", "100000", "rmf-income-rate"}},
+		},
+		{
+			name:   "duplicate donations share the net income cap",
+			income: 1000000,
+			allowances: []Allowance{
+				{AllowanceType: donation, Amount: decimal.NewFromInt(60000)},
+				{AllowanceType: donation, Amount: decimal.NewFromInt(60000)},
+			},
+			expected: []expected{{"60000", "0", ""}, {"40000", "20000", "donation-net-income-rate"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := AllowanceContext{RuleSet: ruleSet, Income: decimal.NewFromInt(tt.income)}
+			accepted := acceptAllowances(tt.allowances, ctx)
+
+			assert.Len(t, accepted, len(tt.expected))
+			for i, allowance := range accepted {
+				assert.Equal(t, tt.expected[i].accepted, allowance.Accepted.String())
+				assert.Equal(t, tt.expected[i].reduction, allowance.Reduction.String())
+				assert.Equal(t, tt.expected[i].limitedBy, allowance.LimitedBy)
+			}
+		})
+	}
+}
+
+func TestAllowanceRegistry_WithCapGroups(t *testing.T) {
+	original := allowanceTypes
+	defer func() { allowanceTypes = original }()
+
+	allowanceTypes = newAllowanceRegistry()
+	for _, name := range original.names {
+		allowanceTypes.register(original.types[name])
+	}
+
+	allowanceTypes.withCapGroups(original.groups...).withCapGroups(CapGroup{
+		Name:  "shopping",
+		Types: []string{donation, kReceipt},
+		Cap: func(ctx AllowanceContext) decimal.Decimal {
+			return decimal.NewFromInt(60000)
+		},
+	})
+
+	result, err := NewCalculator().Calculate(Tax{
+		Income: decimal.NewFromInt(500000),
+		Allowances: []Allowance{
+			{AllowanceType: kReceipt, Amount: decimal.NewFromInt(50000)},
+			{AllowanceType: donation, Amount: decimal.NewFromInt(50000)},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "50000", result.Allowances[0].Accepted.String())
+	assert.Equal(t, "10000", result.Allowances[1].Accepted.String())
+	assert.Equal(t, "23000", result.Tax.String())
+}
+
+func TestAllowanceType_ValidateFamily(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+	ctx := AllowanceContext{RuleSet: ruleSet}
+
+	tests := []struct {
+		name        string
+		allowance   Allowance
+		expectedErr error
+	}{
+		{"spouse without income", Allowance{AllowanceType: spouse}, nil},
+		{"spouse with income", Allowance{AllowanceType: spouse, Income: decimal.NewFromInt(1)}, errs.ErrSpouseHasIncome},
+		{"child with birth year", Allowance{AllowanceType: child, BirthYear: 2562}, nil},
+		{"child without birth year", Allowance{AllowanceType: child}, errs.ErrChildBirthYearRequired},
+		{"parent at minimum age", Allowance{AllowanceType: parent, Age: 60}, nil},
+		{"parent under age", Allowance{AllowanceType: parent, Age: 59}, errs.ErrParentUnderAge},
+		{"parent income at limit", Allowance{AllowanceType: parent, Age: 65, Income: decimal.NewFromInt(30000)}, nil},
+		{"parent income over limit", Allowance{AllowanceType: parent, Age: 65, Income: decimal.NewFromInt(30001)}, errs.ErrParentIncomeExceeded},
+		{"negative income", Allowance{AllowanceType: parent, Age: 65, Income: decimal.NewFromInt(-1)}, errs.ErrValueMustBePositive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowanceType, _ := allowanceTypes.get(tt.allowance.AllowanceType)
+
+			assert.Equal(t, tt.expectedErr, allowanceType.validate(tt.allowance, ctx))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package tax
+
+import (
+	"github.com/shopspring/decimal"
+	"slices"
+	"time"
+)
+
+// Calculator calculates tax with the rule set, rounding and allowance setting
+// it was created with. Its options are copied in by NewCalculator and never
+// change afterwards, and every Tax is calculated on a copy, so one Calculator
+// can be shared between goroutines.
+type Calculator struct {
+	ruleSet          *RuleSet
+	precision        *int32
+	allowanceSetting *AllowanceSetting
+	brackets         []Bracket
+}
+
+type Option func(c *Calculator)
+
+// WithRuleSet uses the rule set for every calculation instead of the one for
+// the Tax's year. Start from GetRuleSet to change only some of the rules.
+func WithRuleSet(ruleSet RuleSet) Option {
+	ruleSet = ruleSet.clone()
+	return func(c *Calculator) {
+		c.ruleSet = &ruleSet
+	}
+}
+
+// WithPrecision rounds amounts to the decimal places instead of the rule
+// set's precision.
+func WithPrecision(places int32) Option {
+	return func(c *Calculator) {
+		c.precision = &places
+	}
+}
+
+// WithAllowanceSetting uses the setting instead of the Tax's AllowanceSetting.
+func WithAllowanceSetting(setting AllowanceSetting) Option {
+	return func(c *Calculator) {
+		c.allowanceSetting = &setting
+	}
+}
+
+// WithBrackets uses the brackets instead of the rule set's brackets. Empty
+// brackets are ignored, as they are on the Tax.
+func WithBrackets(brackets []Bracket) Option {
+	return func(c *Calculator) {
+		c.brackets = slices.Clone(brackets)
+	}
+}
+
+func NewCalculator(opts ...Option) Calculator {
+	var c Calculator
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// Calculate calculates the tax of t with the Calculator's options.
+func (c Calculator) Calculate(t Tax) (Result, error) {
+	if err := c.configure(&t); err != nil {
+		return Result{}, err
+	}
+
+	return calculateCopy(&t)
+}
+
+// Advise suggests how to spend the budget on purchasable allowances.
+func (c Calculator) Advise(t Tax, budget decimal.Decimal) (Advice, error) {
+	if err := c.configure(&t); err != nil {
+		return Advice{}, err
+	}
+
+	return advise(&t, budget)
+}
+
+// GoalSeek finds the smallest income whose calculation reaches the target
+// amount.
+func (c Calculator) GoalSeek(t Tax, target string, amount decimal.Decimal) (GoalSeekResult, error) {
+	if err := c.configure(&t); err != nil {
+		return GoalSeekResult{}, err
+	}
+
+	return goalSeek(&t, target, amount)
+}
+
+// ProjectWithholding spreads the annual tax on salary over the months of the
+// year.
+func (c Calculator) ProjectWithholding(t Tax, salary decimal.Decimal, salaryChanges, bonuses []MonthlyIncome) (WithholdingProjection, error) {
+	if err := c.configure(&t); err != nil {
+		return WithholdingProjection{}, err
+	}
+
+	return projectWithholding(&t, salary, salaryChanges, bonuses)
+}
+
+// CompareFiling calculates a couple's tax filed jointly and separately for
+// the tax year of t.
+func (c Calculator) CompareFiling(t Tax, filer, spouse Filer) (FilingComparison, error) {
+	if err := c.configure(&t); err != nil {
+		return FilingComparison{}, err
+	}
+
+	return compareFiling(&t, filer, spouse)
+}
+
+// CalculatePenalty adds the surcharge and fine for paying the tax of t on the
+// payment date.
+func (c Calculator) CalculatePenalty(t Tax, paymentDate time.Time) (Penalty, error) {
+	if err := c.configure(&t); err != nil {
+		return Penalty{}, err
+	}
+
+	return calculatePenalty(&t, paymentDate)
+}
+
+// CalculatePayout taxes a payout on leaving employment separately from the
+// other income.
+func (c Calculator) CalculatePayout(p Payout) (PayoutResult, error) {
+	ruleSet, err := c.getRuleSet(p.TaxYear)
+	if err != nil {
+		return PayoutResult{}, err
+	}
+
+	return calculatePayout(&p, ruleSet)
+}
+
+// configure sets the rule set and allowance setting t is calculated with.
+func (c Calculator) configure(t *Tax) error {
+	ruleSet, err := c.getRuleSet(t.TaxYear)
+	if err != nil {
+		return err
+	}
+
+	t.ruleSet = &ruleSet
+	if c.allowanceSetting != nil {
+		t.allowanceSetting = *c.allowanceSetting
+	}
+
+	return nil
+}
+
+func (c Calculator) getRuleSet(taxYear int) (RuleSet, error) {
+	var ruleSet RuleSet
+	if c.ruleSet != nil {
+		ruleSet = *c.ruleSet
+	} else {
+		var err error
+		if ruleSet, err = GetRuleSet(taxYear); err != nil {
+			return RuleSet{}, err
+		}
+	}
+
+	if c.precision != nil {
+		ruleSet.Precision = *c.precision
+	}
+
+	if len(c.brackets) > 0 {
+		ruleSet.Brackets = c.brackets
+	}
+
+	return ruleSet, nil
+}
@@ -0,0 +1,113 @@
+package tax
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestCalculator(t *testing.T) {
+	flatRuleSet, _ := GetRuleSet(2567)
+	flatRuleSet.Brackets = []Bracket{{Lower: decimal.Zero, Rate: decimal.RequireFromString("0.1"), NoUpperLimit: true}}
+
+	tests := []struct {
+		name                  string
+		opts                  []Option
+		tax                   Tax
+		expectedTaxableIncome string
+		expectedTax           string
+	}{
+		{
+			name:                  "default options",
+			tax:                   Tax{Income: decimal.RequireFromString("500005.5")},
+			expectedTaxableIncome: "440005.5",
+			expectedTax:           "29000.6",
+		},
+		{
+			name:                  "precision",
+			opts:                  []Option{WithPrecision(0)},
+			tax:                   Tax{Income: decimal.RequireFromString("500005.5")},
+			expectedTaxableIncome: "440005.5",
+			expectedTax:           "29001",
+		},
+		{
+			name:                  "allowance setting",
+			opts:                  []Option{WithAllowanceSetting(AllowanceSetting{Personal: decimal.NewFromInt(100000)})},
+			tax:                   Tax{Income: decimal.NewFromInt(500000)},
+			expectedTaxableIncome: "400000",
+			expectedTax:           "25000",
+		},
+		{
+			name:                  "rule set",
+			opts:                  []Option{WithRuleSet(flatRuleSet)},
+			tax:                   Tax{Income: decimal.NewFromInt(500000)},
+			expectedTaxableIncome: "440000",
+			expectedTax:           "44000",
+		},
+		{
+			name:                  "brackets over the rule set's",
+			opts:                  []Option{WithRuleSet(flatRuleSet), WithBrackets(defaultBrackets)},
+			tax:                   Tax{Income: decimal.NewFromInt(500000)},
+			expectedTaxableIncome: "440000",
+			expectedTax:           "29000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCalculator(tt.opts...).Calculate(tt.tax)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedTaxableIncome, result.TaxableIncome.String())
+			assert.Equal(t, tt.expectedTax, result.Tax.String())
+		})
+	}
+}
+
+func TestCalculator_DoesNotChangeTax(t *testing.T) {
+	allowances := make([]Allowance, 1, 4)
+	allowances[0] = Allowance{AllowanceType: donation, Amount: decimal.NewFromInt(10000)}
+
+	tax := Tax{
+		Income: decimal.NewFromInt(1000000),
+		Incomes: []Income{
+			{Category: salaryIncome, Amount: decimal.NewFromInt(700000)},
+			{Category: salaryIncome, Amount: decimal.NewFromInt(300000), ForeignSource: true},
+		},
+		Allowances:     allowances,
+		DaysInThailand: 90,
+	}
+
+	_, err := NewCalculator().Calculate(tax)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1000000", tax.Income.String())
+	assert.Len(t, tax.Incomes, 2)
+	assert.Len(t, tax.Allowances, 1)
+	assert.Equal(t, Allowance{}, allowances[:2][1])
+}
+
+func TestCalculator_Concurrent(t *testing.T) {
+	calculator := NewCalculator(WithAllowanceSetting(AllowanceSetting{Personal: decimal.NewFromInt(60000)}))
+	tax := Tax{
+		Income:     decimal.NewFromInt(500000),
+		Allowances: make([]Allowance, 0, 4),
+	}
+
+	var wg sync.WaitGroup
+	results := make([]Result, 20)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = calculator.Calculate(tax)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		assert.Equal(t, "29000", result.Tax.String())
+		assert.Len(t, result.Allowances, 1)
+	}
+}
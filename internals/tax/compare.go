@@ -0,0 +1,47 @@
+package tax
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+type Difference struct {
+	Tax       decimal.Decimal
+	Refund    decimal.Decimal
+	NetIncome decimal.Decimal
+	TaxLevels []TaxLevel
+}
+
+// Compare reports how a scenario differs from the base. Tax levels are matched
+// by their description because scenarios may use another year's brackets;
+// levels only one side has are compared against zero.
+func Compare(base, scenario Result) Difference {
+	baseTaxByLevel := map[string]decimal.Decimal{}
+	for _, level := range base.TaxLevels {
+		baseTaxByLevel[level.Level] = level.Tax
+	}
+
+	var taxLevels []TaxLevel
+	seen := map[string]bool{}
+	for _, level := range scenario.TaxLevels {
+		seen[level.Level] = true
+		taxLevels = append(taxLevels, TaxLevel{
+			Level: level.Level,
+			Tax:   level.Tax.Sub(baseTaxByLevel[level.Level]),
+		})
+	}
+	for _, level := range base.TaxLevels {
+		if !seen[level.Level] {
+			taxLevels = append(taxLevels, TaxLevel{
+				Level: level.Level,
+				Tax:   level.Tax.Neg(),
+			})
+		}
+	}
+
+	return Difference{
+		Tax:       scenario.Tax.Sub(base.Tax),
+		Refund:    scenario.Refund.Sub(base.Refund),
+		NetIncome: scenario.NetIncome.Sub(base.NetIncome),
+		TaxLevels: taxLevels,
+	}
+}
@@ -0,0 +1,70 @@
+package tax
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name                string
+		base                Tax
+		scenario            Tax
+		scenarioOptions     []Option
+		expectedTax         string
+		expectedRefund      string
+		expectedNetIncome   string
+		expectedLevelChange map[string]string
+	}{
+		{
+			name:                "extra donation",
+			base:                Tax{Income: decimal.NewFromInt(1000000)},
+			scenario:            Tax{Income: decimal.NewFromInt(1000000), Allowances: []Allowance{{AllowanceType: donation, Amount: decimal.NewFromInt(100000)}}},
+			expectedTax:         "-14100",
+			expectedRefund:      "0",
+			expectedNetIncome:   "14100",
+			expectedLevelChange: map[string]string{"150,001-500,000": "0", "500,001-1,000,000": "-14100"},
+		},
+		{
+			name:                "higher withholding tax",
+			base:                Tax{Income: decimal.NewFromInt(500000)},
+			scenario:            Tax{Income: decimal.NewFromInt(500000), Wht: decimal.NewFromInt(30000)},
+			expectedTax:         "-29000",
+			expectedRefund:      "1000",
+			expectedNetIncome:   "0",
+			expectedLevelChange: map[string]string{"150,001-500,000": "0"},
+		},
+		{
+			name:                "different brackets",
+			base:                Tax{Income: decimal.NewFromInt(500000)},
+			scenario:            Tax{Income: decimal.NewFromInt(500000)},
+			scenarioOptions:     []Option{WithBrackets([]Bracket{{Lower: decimal.Zero, Rate: decimal.RequireFromString("0.1"), NoUpperLimit: true}})},
+			expectedTax:         "15000",
+			expectedRefund:      "0",
+			expectedNetIncome:   "-15000",
+			expectedLevelChange: map[string]string{"0 ขึ้นไป": "44000", "150,001-500,000": "-29000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := NewCalculator().Calculate(tt.base)
+			assert.NoError(t, err)
+			scenario, err := NewCalculator(tt.scenarioOptions...).Calculate(tt.scenario)
+			assert.NoError(t, err)
+
+			difference := Compare(base, scenario)
+			assert.Equal(t, tt.expectedTax, difference.Tax.String())
+			assert.Equal(t, tt.expectedRefund, difference.Refund.String())
+			assert.Equal(t, tt.expectedNetIncome, difference.NetIncome.String())
+			for _, level := range difference.TaxLevels {
+				if expected, ok := tt.expectedLevelChange[level.Level]; ok {
+					assert.Equal(t, expected, level.Tax.String(), level.Level)
+				} else {
+					assert.True(t, level.Tax.IsZero(), level.Level)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+	"slices"
+)
+
+const (
+	finalElection  = "final"
+	creditElection = "credit"
+)
+
+// Dividend is a dividend paid by a Thai company. CorporateRate is the payer's
+// corporate income tax rate, which sets the tax credit when the dividend is
+// included in the return.
+type Dividend struct {
+	Amount        decimal.Decimal
+	CorporateRate decimal.Decimal
+}
+
+// DividendTax is the tax on dividends under an election. Under the final
+// election the withholding is the final tax; under the credit election the
+// dividends grossed up by the credit are taxed with the other income and both
+// the withholding and the credit are offset. FinalTax and CreditTax are the
+// filer's total tax under each election.
+type DividendTax struct {
+	Election  string
+	Amount    decimal.Decimal
+	Withheld  decimal.Decimal
+	Credit    decimal.Decimal
+	Income    decimal.Decimal
+	FinalTax  decimal.Decimal
+	CreditTax decimal.Decimal
+	Cheaper   string
+	Saving    decimal.Decimal
+}
+
+// electDividendTax calculates the tax under both dividend elections and
+// returns the result of the Tax's election, or of the cheaper one when none is
+// given. A tie goes to the final election, which needs nothing in the return.
+func electDividendTax(t *Tax) (Result, error) {
+	if t.DividendElection != "" && t.DividendElection != finalElection && t.DividendElection != creditElection {
+		return Result{}, errs.ErrIncorrectDividendElection
+	}
+
+	results := map[string]Result{}
+	for _, election := range []string{finalElection, creditElection} {
+		tax := *t
+		tax.Allowances = slices.Clone(t.Allowances)
+
+		result, err := calculate(&tax, election)
+		if err != nil {
+			return Result{}, err
+		}
+		results[election] = result
+	}
+
+	finalTax := results[finalElection].Dividends.FinalTax
+	creditTax := results[creditElection].Dividends.CreditTax
+
+	cheaper := finalElection
+	if creditTax.LessThan(finalTax) {
+		cheaper = creditElection
+	}
+
+	election := t.DividendElection
+	if election == "" {
+		election = cheaper
+	}
+
+	result := results[election]
+	result.Dividends.FinalTax = finalTax
+	result.Dividends.CreditTax = creditTax
+	result.Dividends.Cheaper = cheaper
+	result.Dividends.Saving = finalTax.Sub(creditTax).Abs()
+
+	return result, nil
+}
+
+func getDividendTax(dividends []Dividend, election string, ruleSet RuleSet, tr *trace) DividendTax {
+	dividendTax := DividendTax{Election: election}
+
+	for _, dividend := range dividends {
+		withheld := tr.round(dividend.Amount.Mul(ruleSet.DividendWithholdingRate), ruleSet.Precision, "dividend withholding")
+		tr.add(Step{Step: dividendStep, Description: "dividend withholding", Base: dividend.Amount, Rate: ruleSet.DividendWithholdingRate, Amount: withheld})
+
+		dividendTax.Amount = dividendTax.Amount.Add(dividend.Amount)
+		dividendTax.Withheld = dividendTax.Withheld.Add(withheld)
+
+		if election == creditElection {
+			credit := tr.round(getDividendCredit(dividend), ruleSet.Precision, "dividend tax credit")
+			tr.add(Step{Step: dividendStep, Description: "dividend tax credit", Base: dividend.Amount, Rate: dividend.CorporateRate, Amount: credit})
+
+			dividendTax.Credit = dividendTax.Credit.Add(credit)
+		}
+	}
+
+	if election == creditElection {
+		dividendTax.Income = dividendTax.Amount.Add(dividendTax.Credit)
+	}
+
+	return dividendTax
+}
+
+// getDividendCredit returns the corporate tax paid on the profit behind the
+// dividend, amount × rate / (1 - rate).
+func getDividendCredit(dividend Dividend) decimal.Decimal {
+	if dividend.CorporateRate.IsZero() {
+		return decimal.Zero
+	}
+
+	return dividend.Amount.Mul(dividend.CorporateRate).Div(decimal.NewFromInt(1).Sub(dividend.CorporateRate))
+}
+
+func validateDividends(dividends []Dividend) error {
+	for _, dividend := range dividends {
+		if ok := utils.Gte(dividend.Amount, decimal.Zero); !ok {
+			return errs.ErrValueMustBePositive
+		}
+
+		if dividend.CorporateRate.IsNegative() || dividend.CorporateRate.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+			return errs.ErrIncorrectCorporateRate
+		}
+	}
+
+	return nil
+}
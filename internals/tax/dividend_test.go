@@ -0,0 +1,143 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalculateDividends(t *testing.T) {
+	tests := []struct {
+		name              string
+		income            int64
+		corporateRate     string
+		election          string
+		expectedElection  string
+		expectedCheaper   string
+		expectedCredit    string
+		expectedFinalTax  string
+		expectedCreditTax string
+		expectedTax       string
+		expectedRefund    string
+	}{
+		{
+			name:              "credit refunds corporate tax in a low bracket",
+			income:            200000,
+			corporateRate:     "0.20",
+			expectedElection:  creditElection,
+			expectedCheaper:   creditElection,
+			expectedCredit:    "25000",
+			expectedFinalTax:  "10000",
+			expectedCreditTax: "-13500",
+			expectedTax:       "0",
+			expectedRefund:    "23500",
+		},
+		{
+			name:              "final tax is cheaper without corporate tax",
+			income:            2000000,
+			corporateRate:     "0",
+			expectedElection:  finalElection,
+			expectedCheaper:   finalElection,
+			expectedCredit:    "0",
+			expectedFinalTax:  "308000",
+			expectedCreditTax: "324000",
+			expectedTax:       "298000",
+			expectedRefund:    "0",
+		},
+		{
+			name:              "election given",
+			income:            2000000,
+			corporateRate:     "0",
+			election:          creditElection,
+			expectedElection:  creditElection,
+			expectedCheaper:   finalElection,
+			expectedCredit:    "0",
+			expectedFinalTax:  "308000",
+			expectedCreditTax: "324000",
+			expectedTax:       "314000",
+			expectedRefund:    "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCalculator().Calculate(Tax{
+				TaxYear: 2567,
+				Income:  decimal.NewFromInt(tt.income),
+				Dividends: []Dividend{
+					{Amount: decimal.NewFromInt(100000), CorporateRate: decimal.RequireFromString(tt.corporateRate)},
+				},
+				DividendElection: tt.election,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedElection, result.Dividends.Election)
+			assert.Equal(t, tt.expectedCheaper, result.Dividends.Cheaper)
+			assert.Equal(t, "10000", result.Dividends.Withheld.String())
+			assert.Equal(t, tt.expectedCredit, result.Dividends.Credit.String())
+			assert.Equal(t, tt.expectedFinalTax, result.Dividends.FinalTax.String())
+			assert.Equal(t, tt.expectedCreditTax, result.Dividends.CreditTax.String())
+			assert.Equal(t, tt.expectedTax, result.Tax.String())
+			assert.Equal(t, tt.expectedRefund, result.Refund.String())
+		})
+	}
+}
+
+func TestCalculateDividendsError(t *testing.T) {
+	tests := []struct {
+		name        string
+		dividend    Dividend
+		election    string
+		expectedErr error
+	}{
+		{
+			name:        "negative amount",
+			dividend:    Dividend{Amount: decimal.NewFromInt(-1)},
+			expectedErr: errs.ErrValueMustBePositive,
+		},
+		{
+			name:        "corporate rate of 100%",
+			dividend:    Dividend{Amount: decimal.NewFromInt(100000), CorporateRate: decimal.NewFromInt(1)},
+			expectedErr: errs.ErrIncorrectCorporateRate,
+		},
+		{
+			name:        "unknown election",
+			dividend:    Dividend{Amount: decimal.NewFromInt(100000)},
+			election:    "exempt",
+			expectedErr: errs.ErrIncorrectDividendElection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCalculator().Calculate(Tax{
+				TaxYear:          2567,
+				Income:           decimal.NewFromInt(500000),
+				Dividends:        []Dividend{tt.dividend},
+				DividendElection: tt.election,
+			})
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestGetDividendCredit(t *testing.T) {
+	tests := []struct {
+		rate     string
+		expected string
+	}{
+		{"0", "0"},
+		{"0.20", "25000"},
+		{"0.30", "42857.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			credit := getDividendCredit(Dividend{Amount: decimal.NewFromInt(100000), CorporateRate: decimal.RequireFromString(tt.rate)})
+
+			assert.Equal(t, tt.expected, credit.Round(precision).String())
+		})
+	}
+}
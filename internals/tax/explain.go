@@ -0,0 +1,73 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	incomeStep             = "income"
+	dividendStep           = "dividend"
+	expenseStep            = "expense"
+	automaticAllowanceStep = "automatic-allowance"
+	allowanceStep          = "allowance"
+	taxableIncomeStep      = "taxable-income"
+	bracketStep            = "bracket"
+	progressiveTaxStep     = "progressive-tax"
+	minimumTaxStep         = "minimum-tax"
+	taxMethodStep          = "tax-method"
+	whtStep                = "wht"
+	dividendCreditStep     = "dividend-credit"
+	roundingStep           = "rounding"
+	taxStep                = "tax"
+	refundStep             = "refund"
+)
+
+// Step is one line of a calculation trace. Base is the amount the step works
+// on, Rate is applied to it where the step has one, and Amount is the outcome.
+type Step struct {
+	Step        string
+	Description string
+	Base        decimal.Decimal
+	Rate        decimal.Decimal
+	Amount      decimal.Decimal
+}
+
+// trace records the steps of a calculation while it runs. A nil trace records
+// nothing, so the calculation only pays for it in explain mode.
+type trace struct {
+	steps []Step
+}
+
+func newTrace(explain bool) *trace {
+	if !explain {
+		return nil
+	}
+
+	return &trace{}
+}
+
+func (tr *trace) add(step Step) {
+	if tr == nil {
+		return
+	}
+
+	tr.steps = append(tr.steps, step)
+}
+
+func (tr *trace) round(amount decimal.Decimal, places int32, description string) decimal.Decimal {
+	rounded := utils.Round(amount, places)
+	if !rounded.Equal(amount) {
+		tr.add(Step{Step: roundingStep, Description: description, Base: amount, Amount: rounded})
+	}
+
+	return rounded
+}
+
+func (tr *trace) result() []Step {
+	if tr == nil {
+		return nil
+	}
+
+	return tr.steps
+}
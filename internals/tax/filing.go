@@ -0,0 +1,112 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"slices"
+)
+
+const (
+	jointFiling    = "joint"
+	separateFiling = "separate"
+)
+
+type Filer struct {
+	Income     decimal.Decimal
+	Incomes    []Income
+	Wht        decimal.Decimal
+	Allowances []Allowance
+}
+
+type FilingComparison struct {
+	Joint          Result
+	Filer          Result
+	Spouse         Result
+	JointTax       decimal.Decimal
+	SeparateTax    decimal.Decimal
+	Recommendation string
+	Saving         decimal.Decimal
+}
+
+// compareFiling calculates a couple's tax filed jointly and separately. Filed
+// jointly, their incomes, withholding and allowances are combined and each
+// spouse keeps a personal allowance, their own expense group caps and their
+// own allowance caps. The option with the lower tax after withholding is
+// recommended, separate on a tie.
+func compareFiling(t *Tax, filer, spouse Filer) (FilingComparison, error) {
+	if len(filer.Incomes) > 0 && hasTotalIncomeOnly(spouse) || len(spouse.Incomes) > 0 && hasTotalIncomeOnly(filer) {
+		return FilingComparison{}, errs.ErrFilersIncomeMismatch
+	}
+
+	filerResult, err := calculateFiler(t, filer, decimal.Zero)
+	if err != nil {
+		return FilingComparison{}, err
+	}
+
+	spouseResult, err := calculateFiler(t, spouse, decimal.Zero)
+	if err != nil {
+		return FilingComparison{}, err
+	}
+
+	spouseIncome := spouse.Income
+	if len(spouse.Incomes) > 0 {
+		spouseIncome = sumIncomes(spouse.Incomes)
+	}
+
+	jointResult, err := calculateFiler(t, combineFilers(filer, spouse), spouseIncome)
+	if err != nil {
+		return FilingComparison{}, err
+	}
+
+	comparison := FilingComparison{
+		Joint:          jointResult,
+		Filer:          filerResult,
+		Spouse:         spouseResult,
+		JointTax:       getLiability(jointResult),
+		SeparateTax:    getLiability(filerResult).Add(getLiability(spouseResult)),
+		Recommendation: separateFiling,
+	}
+	if comparison.JointTax.LessThan(comparison.SeparateTax) {
+		comparison.Recommendation = jointFiling
+	}
+	comparison.Saving = comparison.JointTax.Sub(comparison.SeparateTax).Abs()
+
+	return comparison, nil
+}
+
+func hasTotalIncomeOnly(filer Filer) bool {
+	return len(filer.Incomes) == 0 && filer.Income.IsPositive()
+}
+
+func combineFilers(filer, spouse Filer) Filer {
+	incomes := slices.Clone(filer.Incomes)
+	for _, income := range spouse.Incomes {
+		income.Spouse = true
+		incomes = append(incomes, income)
+	}
+
+	allowances := slices.Clone(filer.Allowances)
+	for _, allowance := range spouse.Allowances {
+		allowance.Spouse = true
+		allowances = append(allowances, allowance)
+	}
+	allowances = append(allowances, Allowance{AllowanceType: personal, Spouse: true})
+
+	return Filer{
+		Income:     filer.Income.Add(spouse.Income),
+		Incomes:    incomes,
+		Wht:        filer.Wht.Add(spouse.Wht),
+		Allowances: allowances,
+	}
+}
+
+func calculateFiler(t *Tax, filer Filer, spouseIncome decimal.Decimal) (Result, error) {
+	tax := *t
+	tax.Income = filer.Income
+	tax.SpouseIncome = spouseIncome
+	tax.Incomes = filer.Incomes
+	tax.Wht = filer.Wht
+	tax.Allowances = slices.Clone(filer.Allowances)
+
+	return calculateCopy(&tax)
+}
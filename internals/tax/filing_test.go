@@ -0,0 +1,90 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompareFiling(t *testing.T) {
+	salary := func(amount int64) []Income {
+		return []Income{{Category: salaryIncome, Amount: decimal.NewFromInt(amount)}}
+	}
+
+	tests := []struct {
+		name                   string
+		filer                  Filer
+		spouse                 Filer
+		expectedJointTax       string
+		expectedSeparateTax    string
+		expectedRecommendation string
+		expectedSaving         string
+		expectedErr            error
+	}{
+		{
+			name:                   "spouse without income",
+			filer:                  Filer{Incomes: salary(1000000)},
+			spouse:                 Filer{},
+			expectedJointTax:       "77000",
+			expectedSeparateTax:    "86000",
+			expectedRecommendation: jointFiling,
+			expectedSaving:         "9000",
+		},
+		{
+			name:                   "both spouses earn",
+			filer:                  Filer{Incomes: salary(1000000)},
+			spouse:                 Filer{Incomes: salary(1000000)},
+			expectedJointTax:       "246000",
+			expectedSeparateTax:    "172000",
+			expectedRecommendation: separateFiling,
+			expectedSaving:         "74000",
+		},
+		{
+			name:                   "withholding tax and allowances",
+			filer:                  Filer{Income: decimal.NewFromInt(500000), Wht: decimal.NewFromInt(30000)},
+			spouse:                 Filer{Income: decimal.NewFromInt(100000), Allowances: []Allowance{{AllowanceType: kReceipt, Amount: decimal.NewFromInt(50000)}}},
+			expectedJointTax:       "-2000",
+			expectedSeparateTax:    "-1000",
+			expectedRecommendation: jointFiling,
+			expectedSaving:         "1000",
+		},
+		{
+			name: "both spouses claim insurance and retirement",
+			filer: Filer{Incomes: salary(1000000), Allowances: []Allowance{
+				{AllowanceType: lifeInsurance, Amount: decimal.NewFromInt(100000)},
+				{AllowanceType: rmf, Amount: decimal.NewFromInt(300000)},
+			}},
+			spouse: Filer{Incomes: salary(1000000), Allowances: []Allowance{
+				{AllowanceType: lifeInsurance, Amount: decimal.NewFromInt(100000)},
+				{AllowanceType: rmf, Amount: decimal.NewFromInt(300000)},
+			}},
+			expectedJointTax:       "92000",
+			expectedSeparateTax:    "58000",
+			expectedRecommendation: separateFiling,
+			expectedSaving:         "34000",
+		},
+		{
+			name:        "mixed total and categorized incomes",
+			filer:       Filer{Incomes: salary(1000000)},
+			spouse:      Filer{Income: decimal.NewFromInt(100000)},
+			expectedErr: errs.ErrFilersIncomeMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison, err := NewCalculator().CompareFiling(Tax{}, tt.filer, tt.spouse)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedJointTax, comparison.JointTax.String())
+			assert.Equal(t, tt.expectedSeparateTax, comparison.SeparateTax.String())
+			assert.Equal(t, tt.expectedRecommendation, comparison.Recommendation)
+			assert.Equal(t, tt.expectedSaving, comparison.Saving.String())
+		})
+	}
+}
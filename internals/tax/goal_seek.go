@@ -0,0 +1,132 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"slices"
+)
+
+const (
+	targetTax       = "tax"
+	targetNetIncome = "netIncome"
+	targetRefund    = "refund"
+)
+
+const (
+	goalSeekTolerance     = "0.01"
+	goalSeekMaxIncome     = 1000000000000
+	goalSeekInitialIncome = 1000000
+)
+
+type GoalSeekResult struct {
+	Income    decimal.Decimal
+	NetIncome decimal.Decimal
+	Result    Result
+}
+
+// goalSeek finds the smallest income whose calculation reaches the target
+// amount. Tax and net income grow with income while the refund shrinks, so
+// the income is bisected between the withholding tax and an upper bound. A
+// single categorized income, such as a salary, is sought as the amount of
+// that income.
+func goalSeek(t *Tax, target string, amount decimal.Decimal) (GoalSeekResult, error) {
+	value := func(r GoalSeekResult) decimal.Decimal {
+		switch target {
+		case targetTax:
+			return r.Result.Tax
+		case targetNetIncome:
+			return r.NetIncome
+		default:
+			return r.Result.Refund.Neg()
+		}
+	}
+
+	if ok := slices.Contains([]string{targetTax, targetNetIncome, targetRefund}, target); !ok {
+		return GoalSeekResult{}, errs.ErrIncorrectGoalTarget
+	}
+
+	if len(t.Incomes) > 1 {
+		return GoalSeekResult{}, errs.ErrGoalSeekIncomes
+	}
+
+	if target == targetRefund {
+		amount = amount.Neg()
+	}
+	reached := func(r GoalSeekResult) bool {
+		return value(r).GreaterThanOrEqual(amount)
+	}
+
+	low := t.Wht
+	lowResult, err := calculateForIncome(t, low)
+	if err != nil {
+		return GoalSeekResult{}, err
+	}
+	if reached(lowResult) {
+		if !value(lowResult).Equal(amount) {
+			return GoalSeekResult{}, errs.ErrGoalUnreachable
+		}
+		return lowResult, nil
+	}
+
+	high := decimal.Max(decimal.NewFromInt(goalSeekInitialIncome), low.Mul(decimal.NewFromInt(2)))
+	highResult, err := calculateForIncome(t, high)
+	if err != nil {
+		return GoalSeekResult{}, err
+	}
+	for !reached(highResult) {
+		if high.GreaterThanOrEqual(decimal.NewFromInt(goalSeekMaxIncome)) {
+			return GoalSeekResult{}, errs.ErrGoalUnreachable
+		}
+
+		low = high
+		high = high.Mul(decimal.NewFromInt(2))
+		highResult, err = calculateForIncome(t, high)
+		if err != nil {
+			return GoalSeekResult{}, err
+		}
+	}
+
+	tolerance := decimal.RequireFromString(goalSeekTolerance)
+	for high.Sub(low).GreaterThan(tolerance) {
+		mid := low.Add(high).Div(decimal.NewFromInt(2)).Round(2)
+		if mid.Equal(low) || mid.Equal(high) {
+			break
+		}
+
+		midResult, err := calculateForIncome(t, mid)
+		if err != nil {
+			return GoalSeekResult{}, err
+		}
+
+		if reached(midResult) {
+			high, highResult = mid, midResult
+		} else {
+			low = mid
+		}
+	}
+
+	return highResult, nil
+}
+
+func calculateForIncome(t *Tax, income decimal.Decimal) (GoalSeekResult, error) {
+	tax := *t
+	tax.Income = income
+	tax.Allowances = slices.Clone(t.Allowances)
+	if len(t.Incomes) == 1 {
+		tax.Incomes = []Income{t.Incomes[0]}
+		tax.Incomes[0].Amount = income
+	}
+
+	result, err := calculateCopy(&tax)
+	if err != nil {
+		return GoalSeekResult{}, err
+	}
+
+	paid := result.Tax.Add(tax.Wht).Sub(result.Refund)
+
+	return GoalSeekResult{
+		Income:    income,
+		NetIncome: income.Sub(paid),
+		Result:    result,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGoalSeek(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		amount         float64
+		wht            float64
+		allowances     []Allowance
+		expectedIncome float64
+		expectedErr    error
+	}{
+		{
+			name:           "target tax",
+			target:         targetTax,
+			amount:         29000,
+			expectedIncome: 499999.5,
+		},
+		{
+			name:           "target net income",
+			target:         targetNetIncome,
+			amount:         400000,
+			expectedIncome: 421111.1,
+		},
+		{
+			name:           "target net income across brackets",
+			target:         targetNetIncome,
+			amount:         1000000,
+			expectedIncome: 1122500,
+		},
+		{
+			name:           "target net income with allowances",
+			target:         targetNetIncome,
+			amount:         400000,
+			allowances:     []Allowance{{AllowanceType: kReceipt, Amount: decimal.NewFromInt(50000)}},
+			expectedIncome: 415555.6,
+		},
+		{
+			name:           "target refund",
+			target:         targetRefund,
+			amount:         10000,
+			wht:            50000,
+			expectedIncome: 593333,
+		},
+		{
+			name:           "target zero tax",
+			target:         targetTax,
+			amount:         0,
+			expectedIncome: 0,
+		},
+		{
+			name:        "refund above withholding tax",
+			target:      targetRefund,
+			amount:      60000,
+			wht:         50000,
+			expectedErr: errs.ErrGoalUnreachable,
+		},
+		{
+			name:        "incorrect target",
+			target:      "income",
+			amount:      1000,
+			expectedErr: errs.ErrIncorrectGoalTarget,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCalculator().GoalSeek(Tax{
+				Wht:        decimal.NewFromFloat(tt.wht),
+				Allowances: tt.allowances,
+			}, tt.target, decimal.NewFromFloat(tt.amount))
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.True(t, result.Income.Sub(decimal.NewFromFloat(tt.expectedIncome)).Abs().LessThanOrEqual(decimal.RequireFromString("0.01")),
+				"income %s", result.Income)
+		})
+	}
+
+	t.Run("categorized salary", func(t *testing.T) {
+		result, err := NewCalculator().GoalSeek(Tax{
+			Incomes: []Income{{Category: salaryIncome, Amount: decimal.NewFromInt(100000)}},
+		}, targetTax, decimal.NewFromInt(29000))
+
+		assert.NoError(t, err)
+		assert.True(t, result.Income.Sub(decimal.NewFromFloat(599999.5)).Abs().LessThanOrEqual(decimal.RequireFromString("0.01")),
+			"income %s", result.Income)
+		assert.Equal(t, "29000", result.Result.Tax.String())
+	})
+
+	t.Run("more than one categorized income", func(t *testing.T) {
+		_, err := NewCalculator().GoalSeek(Tax{
+			Incomes: []Income{
+				{Category: salaryIncome, Amount: decimal.NewFromInt(100000)},
+				{Category: rentalIncome, Amount: decimal.NewFromInt(100000)},
+			},
+		}, targetTax, decimal.NewFromInt(10000))
+
+		assert.ErrorIs(t, err, errs.ErrGoalSeekIncomes)
+	})
+
+	t.Run("does not change the given tax", func(t *testing.T) {
+		tax := Tax{Allowances: make([]Allowance, 1, 4)}
+		tax.Allowances[0] = Allowance{AllowanceType: donation, Amount: decimal.NewFromInt(1000)}
+
+		_, err := NewCalculator().GoalSeek(tax, targetTax, decimal.NewFromInt(10000))
+
+		assert.NoError(t, err)
+		assert.Len(t, tax.Allowances, 1)
+		assert.Equal(t, Allowance{}, tax.Allowances[:2][1])
+	})
+}
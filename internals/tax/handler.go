@@ -1,50 +1,338 @@
 package tax
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/internals/models"
 	"github.com/Atvit/assessment-tax/internals/setting"
 	"github.com/Atvit/assessment-tax/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type AllowanceRequest struct {
-	AllowanceType string  `json:"allowanceType" validate:"omitempty,oneof=donation k-receipt"`
-	Amount        float64 `json:"amount" validate:"omitempty,gte=0"`
+	AllowanceType string          `json:"allowanceType"`
+	Amount        decimal.Decimal `json:"amount" validate:"omitempty,gte=0"`
+	Category      string          `json:"category"`
+	BirthYear     int             `json:"birthYear" validate:"omitempty,gt=0"`
+	Age           int             `json:"age" validate:"omitempty,gt=0"`
+	Income        decimal.Decimal `json:"income" validate:"omitempty,gte=0"`
+}
+
+type IncomeRequest struct {
+	Category      string          `json:"category" validate:"required"`
+	Amount        decimal.Decimal `json:"amount" validate:"omitempty,gte=0"`
+	Expense       decimal.Decimal `json:"expense" validate:"omitempty,gte=0"`
+	Wht           decimal.Decimal `json:"wht" validate:"omitempty,gte=0"`
+	Currency      string          `json:"currency" validate:"omitempty,len=3"`
+	Date          string          `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	ForeignSource bool            `json:"foreignSource"`
+}
+
+type DividendRequest struct {
+	Amount        decimal.Decimal `json:"amount" validate:"gte=0"`
+	CorporateRate decimal.Decimal `json:"corporateRate" validate:"gte=0,lt=1"`
 }
 
 type Request struct {
-	TotalIncome float64            `json:"totalIncome" validate:"required,gte=0"`
-	Wht         float64            `json:"wht" validate:"omitempty,gte=0,ltefield=TotalIncome"`
-	Allowances  []AllowanceRequest `json:"allowances" validate:"dive"`
+	TaxYear          int                `json:"taxYear" validate:"omitempty,gt=0"`
+	TotalIncome      decimal.Decimal    `json:"totalIncome" validate:"required,gte=0"`
+	Incomes          []IncomeRequest    `json:"incomes" validate:"dive"`
+	Wht              decimal.Decimal    `json:"wht" validate:"omitempty,gte=0,ltefield=TotalIncome"`
+	Allowances       []AllowanceRequest `json:"allowances" validate:"dive"`
+	Dividends        []DividendRequest  `json:"dividends" validate:"dive"`
+	DividendElection string             `json:"dividendElection" validate:"omitempty,oneof=final credit"`
+	Residency        string             `json:"residency" validate:"omitempty,oneof=resident non-resident"`
+	DaysInThailand   int                `json:"daysInThailand" validate:"omitempty,gte=0,lte=366"`
+	Explain          bool               `json:"explain"`
+	FilingDate       string             `json:"filingDate" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type AllowanceResponse struct {
+	AllowanceType string           `json:"allowanceType"`
+	Category      string           `json:"category,omitempty"`
+	Amount        decimal.Decimal  `json:"amount"`
+	Accepted      decimal.Decimal  `json:"accepted"`
+	Reduction     *decimal.Decimal `json:"reduction,omitempty"`
+	LimitedBy     string           `json:"limitedBy,omitempty"`
+	Warning       string           `json:"warning,omitempty"`
+	BirthYear     int              `json:"birthYear,omitempty"`
+	Age           int              `json:"age,omitempty"`
+}
+
+type IncomeResponse struct {
+	Category      string          `json:"category"`
+	Amount        decimal.Decimal `json:"amount"`
+	Expense       decimal.Decimal `json:"expense"`
+	ExpenseMethod string          `json:"expenseMethod"`
+	NetIncome     decimal.Decimal `json:"netIncome"`
+}
+
+type StepResponse struct {
+	Step        string           `json:"step"`
+	Description string           `json:"description"`
+	Base        *decimal.Decimal `json:"base,omitempty"`
+	Rate        *decimal.Decimal `json:"rate,omitempty"`
+	Amount      decimal.Decimal  `json:"amount"`
+}
+
+type InstallmentResponse struct {
+	Installment int             `json:"installment"`
+	DueDate     string          `json:"dueDate"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+type DividendResponse struct {
+	Election  string          `json:"election"`
+	Amount    decimal.Decimal `json:"amount"`
+	Withheld  decimal.Decimal `json:"withheld"`
+	Credit    decimal.Decimal `json:"credit"`
+	FinalTax  decimal.Decimal `json:"finalTax"`
+	CreditTax decimal.Decimal `json:"creditTax"`
+	Cheaper   string          `json:"cheaper"`
+	Saving    decimal.Decimal `json:"saving"`
+}
+
+type ConversionResponse struct {
+	Category   string          `json:"category"`
+	Currency   string          `json:"currency"`
+	Date       string          `json:"date"`
+	Rate       decimal.Decimal `json:"rate"`
+	Amount     decimal.Decimal `json:"amount"`
+	Expense    decimal.Decimal `json:"expense"`
+	Wht        decimal.Decimal `json:"wht"`
+	THBAmount  decimal.Decimal `json:"thbAmount"`
+	THBExpense decimal.Decimal `json:"thbExpense"`
+	THBWht     decimal.Decimal `json:"thbWht"`
+}
+
+type ResidencyResponse struct {
+	Status   string          `json:"status"`
+	Days     int             `json:"days,omitempty"`
+	PartYear bool            `json:"partYear"`
+	Rate     decimal.Decimal `json:"rate"`
 }
 
 type Response struct {
-	Tax       float64    `json:"tax"`
-	TaxLevel  []TaxLevel `json:"taxLevel,omitempty"`
-	TaxRefund float64    `json:"taxRefund,omitempty"`
+	Tax           decimal.Decimal       `json:"tax"`
+	Method        string                `json:"method,omitempty"`
+	TaxLevel      []TaxLevel            `json:"taxLevel,omitempty"`
+	TaxRefund     *decimal.Decimal      `json:"taxRefund,omitempty"`
+	TaxableIncome decimal.Decimal       `json:"taxableIncome"`
+	Deductions    decimal.Decimal       `json:"deductions"`
+	EffectiveRate decimal.Decimal       `json:"effectiveRate"`
+	MarginalRate  decimal.Decimal       `json:"marginalRate"`
+	Bracket       string                `json:"bracket"`
+	NetIncome     decimal.Decimal       `json:"netIncome"`
+	Incomes       []IncomeResponse      `json:"incomes,omitempty"`
+	Allowances    []AllowanceResponse   `json:"allowances,omitempty"`
+	Explanation   []StepResponse        `json:"explanation,omitempty"`
+	Installments  []InstallmentResponse `json:"installments,omitempty"`
+	Dividends     *DividendResponse     `json:"dividends,omitempty"`
+	Conversions   []ConversionResponse  `json:"conversions,omitempty"`
+	Residency     *ResidencyResponse    `json:"residency,omitempty"`
 }
 
 type CSVData struct {
-	TotalIncome float64 `csv:"totalIncome" validate:"required,gte=0"`
-	Wht         float64 `csv:"wht" validate:"omitempty,gte=0,ltefield=TotalIncome"`
-	Donation    float64 `csv:"donation" validate:"omitempty,gte=0"`
+	TaxYear     int             `csv:"taxYear" validate:"omitempty,gt=0"`
+	TotalIncome decimal.Decimal `csv:"totalIncome" validate:"required,gte=0"`
+	Wht         decimal.Decimal `csv:"wht" validate:"omitempty,gte=0,ltefield=TotalIncome"`
+	Donation    decimal.Decimal `csv:"donation" validate:"omitempty,gte=0"`
 }
 
 type UploadCSVResponseData struct {
-	TotalIncome float64 `json:"totalIncome"`
-	Tax         float64 `json:"tax"`
-	TaxRefund   float64 `json:"taxRefund,omitempty"`
+	TotalIncome   decimal.Decimal  `json:"totalIncome"`
+	Tax           decimal.Decimal  `json:"tax"`
+	TaxRefund     *decimal.Decimal `json:"taxRefund,omitempty"`
+	TaxableIncome decimal.Decimal  `json:"taxableIncome"`
+	Deductions    decimal.Decimal  `json:"deductions"`
+	EffectiveRate decimal.Decimal  `json:"effectiveRate"`
+	MarginalRate  decimal.Decimal  `json:"marginalRate"`
+	Bracket       string           `json:"bracket"`
+	NetIncome     decimal.Decimal  `json:"netIncome"`
 }
 
 type UploadCSVResponse struct {
 	Taxes []UploadCSVResponseData `json:"taxes"`
 }
 
+type GoalSeekRequest struct {
+	TaxYear    int                `json:"taxYear" validate:"omitempty,gt=0"`
+	Target     string             `json:"target" validate:"required,oneof=tax netIncome refund"`
+	Amount     decimal.Decimal    `json:"amount" validate:"gte=0"`
+	Wht        decimal.Decimal    `json:"wht" validate:"omitempty,gte=0"`
+	Allowances []AllowanceRequest `json:"allowances" validate:"dive"`
+}
+
+type GoalSeekResponse struct {
+	TotalIncome decimal.Decimal  `json:"totalIncome"`
+	NetIncome   decimal.Decimal  `json:"netIncome"`
+	Tax         decimal.Decimal  `json:"tax"`
+	TaxRefund   *decimal.Decimal `json:"taxRefund,omitempty"`
+}
+
+type AdviceRequest struct {
+	Request
+	Budget decimal.Decimal `json:"budget" validate:"omitempty,gte=0"`
+}
+
+type HeadroomResponse struct {
+	AllowanceType string          `json:"allowanceType"`
+	Amount        decimal.Decimal `json:"amount"`
+}
+
+type SuggestionResponse struct {
+	AllowanceType string          `json:"allowanceType"`
+	Amount        decimal.Decimal `json:"amount"`
+	TaxSaved      decimal.Decimal `json:"taxSaved"`
+	Message       string          `json:"message"`
+}
+
+type AdviceResponse struct {
+	Tax             decimal.Decimal      `json:"tax"`
+	TaxRefund       *decimal.Decimal     `json:"taxRefund,omitempty"`
+	MarginalRate    decimal.Decimal      `json:"marginalRate"`
+	TaxSavedPerBaht decimal.Decimal      `json:"taxSavedPerBaht"`
+	Headroom        []HeadroomResponse   `json:"headroom"`
+	Suggestions     []SuggestionResponse `json:"suggestions,omitempty"`
+	TaxSaved        decimal.Decimal      `json:"taxSaved"`
+}
+
+type MonthlyIncomeRequest struct {
+	Month  int             `json:"month" validate:"gte=1,lte=12"`
+	Amount decimal.Decimal `json:"amount" validate:"gte=0"`
+}
+
+type WithholdingRequest struct {
+	TaxYear       int                    `json:"taxYear" validate:"omitempty,gt=0"`
+	Salary        decimal.Decimal        `json:"salary" validate:"required,gte=0"`
+	SalaryChanges []MonthlyIncomeRequest `json:"salaryChanges" validate:"dive"`
+	Bonuses       []MonthlyIncomeRequest `json:"bonuses" validate:"dive"`
+	Allowances    []AllowanceRequest     `json:"allowances" validate:"dive"`
+}
+
+type MonthlyWithholdingResponse struct {
+	Month       int             `json:"month"`
+	Salary      decimal.Decimal `json:"salary"`
+	Bonus       decimal.Decimal `json:"bonus"`
+	Withholding decimal.Decimal `json:"withholding"`
+}
+
+type WithholdingResponse struct {
+	AnnualIncome     decimal.Decimal              `json:"annualIncome"`
+	AnnualTax        decimal.Decimal              `json:"annualTax"`
+	TotalWithholding decimal.Decimal              `json:"totalWithholding"`
+	OverWithheld     decimal.Decimal              `json:"overWithheld"`
+	Months           []MonthlyWithholdingResponse `json:"months"`
+}
+
+type FilerRequest struct {
+	TotalIncome decimal.Decimal    `json:"totalIncome" validate:"gte=0"`
+	Incomes     []IncomeRequest    `json:"incomes" validate:"dive"`
+	Wht         decimal.Decimal    `json:"wht" validate:"omitempty,gte=0,ltefield=TotalIncome"`
+	Allowances  []AllowanceRequest `json:"allowances" validate:"dive"`
+}
+
+type FilingRequest struct {
+	TaxYear int          `json:"taxYear" validate:"omitempty,gt=0"`
+	Filer   FilerRequest `json:"filer"`
+	Spouse  FilerRequest `json:"spouse"`
+}
+
+type SeparateFilingResponse struct {
+	Filer  Response `json:"filer"`
+	Spouse Response `json:"spouse"`
+}
+
+type FilingComparisonResponse struct {
+	Joint          Response               `json:"joint"`
+	Separate       SeparateFilingResponse `json:"separate"`
+	JointTax       decimal.Decimal        `json:"jointTax"`
+	SeparateTax    decimal.Decimal        `json:"separateTax"`
+	Recommendation string                 `json:"recommendation"`
+	Saving         decimal.Decimal        `json:"saving"`
+}
+
+type ScenarioRequest struct {
+	Name          string             `json:"name" validate:"required"`
+	Override      json.RawMessage    `json:"override"`
+	AddAllowances []AllowanceRequest `json:"addAllowances" validate:"dive"`
+}
+
+type CompareRequest struct {
+	Base      Request           `json:"base"`
+	Scenarios []ScenarioRequest `json:"scenarios" validate:"required,dive"`
+}
+
+type DifferenceResponse struct {
+	Tax       decimal.Decimal `json:"tax"`
+	TaxRefund decimal.Decimal `json:"taxRefund"`
+	NetIncome decimal.Decimal `json:"netIncome"`
+	TaxLevel  []TaxLevel      `json:"taxLevel"`
+}
+
+type ScenarioResponse struct {
+	Name       string             `json:"name"`
+	Result     Response           `json:"result"`
+	Difference DifferenceResponse `json:"difference"`
+}
+
+type CompareResponse struct {
+	Base      Response           `json:"base"`
+	Scenarios []ScenarioResponse `json:"scenarios"`
+}
+
+type PenaltyRequest struct {
+	Request
+	PaymentDate string `json:"paymentDate" validate:"required,datetime=2006-01-02"`
+}
+
+type PenaltyResponse struct {
+	Tax       decimal.Decimal `json:"tax"`
+	Deadline  string          `json:"deadline"`
+	Months    int             `json:"months"`
+	Surcharge decimal.Decimal `json:"surcharge"`
+	Fine      decimal.Decimal `json:"fine"`
+	Total     decimal.Decimal `json:"total"`
+}
+
+type PayoutRequest struct {
+	TaxYear        int             `json:"taxYear" validate:"omitempty,gt=0"`
+	Severance      decimal.Decimal `json:"severance" validate:"omitempty,gte=0"`
+	ProvidentFund  decimal.Decimal `json:"providentFund" validate:"omitempty,gte=0"`
+	YearsOfService int             `json:"yearsOfService" validate:"required,gt=0"`
+	LastSalary     decimal.Decimal `json:"lastSalary" validate:"omitempty,gte=0"`
+	Wht            decimal.Decimal `json:"wht" validate:"omitempty,gte=0"`
+}
+
+type PayoutResponse struct {
+	Tax           decimal.Decimal  `json:"tax"`
+	TaxRefund     *decimal.Decimal `json:"taxRefund,omitempty"`
+	TaxLevel      []TaxLevel       `json:"taxLevel"`
+	Income        decimal.Decimal  `json:"income"`
+	Exemption     decimal.Decimal  `json:"exemption"`
+	Expense       decimal.Decimal  `json:"expense"`
+	TaxableIncome decimal.Decimal  `json:"taxableIncome"`
+}
+
 type Handler interface {
 	CalculateTax(c echo.Context) error
+	GoalSeek(c echo.Context) error
+	Advise(c echo.Context) error
+	ProjectWithholding(c echo.Context) error
+	CompareFiling(c echo.Context) error
+	CompareScenarios(c echo.Context) error
+	CalculatePenalty(c echo.Context) error
+	CalculatePayout(c echo.Context) error
 	UploadCSV(c echo.Context) error
 }
 
@@ -59,6 +347,8 @@ func NewHandler(
 	validate *validator.Validate,
 	settingRepo setting.Repository,
 ) Handler {
+	registerValidations(validate)
+
 	return handler{
 		logger:      logger,
 		validate:    validate,
@@ -76,48 +366,371 @@ func (h handler) CalculateTax(c echo.Context) error {
 		})
 	}
 
-	if err := h.validate.Struct(req); err != nil {
-		h.logger.Error("validate request body failed", zap.Error(err))
+	calculator, conversions, status, err := h.prepare(req.TaxYear, &req, req.incomeLines())
+	if err != nil {
+		return c.JSON(status, newErrResponse(err))
+	}
+
+	result, err := calculator.Calculate(toTax(req))
+	if err != nil {
+		h.logger.Error("tax calculation failed", zap.Error(err))
 		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
-			Error: utils.GetValidateErrMsg(err),
+			Error: err.Error(),
 		})
 	}
 
-	allowanceSetting, err := h.settingRepo.Get()
+	resp := newResponse(result)
+	resp.Conversions = conversions
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h handler) GoalSeek(c echo.Context) error {
+	var req GoalSeekRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	calculator, _, status, err := h.prepare(req.TaxYear, &req)
 	if err != nil {
-		h.logger.Error("get allowance setting failed", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+		return c.JSON(status, newErrResponse(err))
+	}
+
+	result, err := calculator.GoalSeek(Tax{
+		TaxYear:    req.TaxYear,
+		Wht:        req.Wht,
+		Allowances: toAllowances(req.Allowances),
+	}, req.Target, req.Amount)
+	if err != nil {
+		h.logger.Error("goal seek failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
 			Error: err.Error(),
 		})
 	}
 
-	var taxAllowances []Allowance
-	for _, allowances := range req.Allowances {
-		taxAllowances = append(taxAllowances, Allowance{
-			AllowanceType: allowances.AllowanceType,
-			Amount:        allowances.Amount,
+	return c.JSON(http.StatusOK, GoalSeekResponse{
+		TotalIncome: result.Income,
+		NetIncome:   result.NetIncome,
+		Tax:         result.Result.Tax,
+		TaxRefund:   nonZero(result.Result.Refund),
+	})
+}
+
+func (h handler) Advise(c echo.Context) error {
+	var req AdviceRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
 		})
 	}
-	taxAmount, refundAmount, taxLevels, err := Calculate(&Tax{
+
+	calculator, _, status, err := h.prepare(req.TaxYear, &req, req.incomeLines())
+	if err != nil {
+		return c.JSON(status, newErrResponse(err))
+	}
+
+	advice, err := calculator.Advise(Tax{
+		TaxYear:    req.TaxYear,
 		Income:     req.TotalIncome,
+		Incomes:    toIncomes(req.Incomes),
 		Wht:        req.Wht,
-		Allowances: taxAllowances,
-		AllowanceSetting: AllowanceSetting{
-			Personal: allowanceSetting.Personal,
-			KReceipt: allowanceSetting.KReceipt,
+		Allowances: toAllowances(req.Allowances),
+	}, req.Budget)
+	if err != nil {
+		h.logger.Error("tax advice failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	resp := AdviceResponse{
+		Tax:             advice.Result.Tax,
+		TaxRefund:       nonZero(advice.Result.Refund),
+		MarginalRate:    advice.Result.MarginalRate,
+		TaxSavedPerBaht: advice.TaxSavedPerBaht,
+		TaxSaved:        advice.TaxSaved,
+	}
+	for _, headroom := range advice.Headroom {
+		resp.Headroom = append(resp.Headroom, HeadroomResponse{
+			AllowanceType: headroom.AllowanceType,
+			Amount:        headroom.Amount,
+		})
+	}
+	for _, suggestion := range advice.Suggestions {
+		resp.Suggestions = append(resp.Suggestions, SuggestionResponse{
+			AllowanceType: suggestion.AllowanceType,
+			Amount:        suggestion.Amount,
+			TaxSaved:      suggestion.TaxSaved,
+			Message: fmt.Sprintf("you can save %s by buying %s of %s",
+				utils.FormatNumber(suggestion.TaxSaved), utils.FormatNumber(suggestion.Amount), suggestion.AllowanceType),
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h handler) ProjectWithholding(c echo.Context) error {
+	var req WithholdingRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	calculator, _, status, err := h.prepare(req.TaxYear, &req)
+	if err != nil {
+		return c.JSON(status, newErrResponse(err))
+	}
+
+	projection, err := calculator.ProjectWithholding(Tax{
+		TaxYear:    req.TaxYear,
+		Allowances: toAllowances(req.Allowances),
+	}, req.Salary, toMonthlyIncomes(req.SalaryChanges), toMonthlyIncomes(req.Bonuses))
+	if err != nil {
+		h.logger.Error("withholding projection failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	resp := WithholdingResponse{
+		AnnualIncome:     projection.AnnualIncome,
+		AnnualTax:        projection.AnnualTax,
+		TotalWithholding: projection.TotalWithholding,
+		OverWithheld:     projection.OverWithheld,
+	}
+	for _, month := range projection.Months {
+		resp.Months = append(resp.Months, MonthlyWithholdingResponse{
+			Month:       month.Month,
+			Salary:      month.Salary,
+			Bonus:       month.Bonus,
+			Withholding: month.Withholding,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h handler) CompareFiling(c echo.Context) error {
+	var req FilingRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	calculator, _, status, err := h.prepare(req.TaxYear, &req, req.Filer.incomeLines(), req.Spouse.incomeLines())
+	if err != nil {
+		return c.JSON(status, newErrResponse(err))
+	}
+
+	filer, spouse := toFiler(req.Filer), toFiler(req.Spouse)
+	comparison, err := calculator.CompareFiling(Tax{TaxYear: req.TaxYear}, filer, spouse)
+	if err != nil {
+		h.logger.Error("filing comparison failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, FilingComparisonResponse{
+		Joint: newResponse(comparison.Joint),
+		Separate: SeparateFilingResponse{
+			Filer:  newResponse(comparison.Filer),
+			Spouse: newResponse(comparison.Spouse),
 		},
+		JointTax:       comparison.JointTax,
+		SeparateTax:    comparison.SeparateTax,
+		Recommendation: comparison.Recommendation,
+		Saving:         comparison.Saving,
 	})
+}
+
+func (h handler) CompareScenarios(c echo.Context) error {
+	var req CompareRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if _, status, err := h.validateRequest(&req, req.Base.incomeLines()); err != nil {
+		return c.JSON(status, newErrResponse(err))
+	}
+
+	scenarios := make([]Request, 0, len(req.Scenarios))
+	for _, scenario := range req.Scenarios {
+		scenarioReq, err := applyScenario(req.Base, scenario)
+		if err != nil {
+			h.logger.Error("apply scenario failed", zap.String("scenario", scenario.Name), zap.Error(err))
+			return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+				Error: fmt.Sprintf("scenario %s: %s", scenario.Name, err.Error()),
+			})
+		}
+
+		if _, status, err := h.validateRequest(&scenarioReq, scenarioReq.incomeLines()); err != nil {
+			return c.JSON(status, newErrResponse(fmt.Errorf("scenario %s: %w", scenario.Name, err)))
+		}
+
+		scenarios = append(scenarios, scenarioReq)
+	}
+
+	allowanceSetting, err := h.settingRepo.Get()
 	if err != nil {
-		h.logger.Error("tax calculation failed", zap.Error(err))
+		h.logger.Error("get allowance setting failed", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	bracketsByYear := map[int][]Bracket{}
+	calculate := func(req Request) (Result, int, error) {
+		brackets, ok := bracketsByYear[req.TaxYear]
+		if !ok {
+			brackets, err = h.getBrackets(req.TaxYear)
+			if err != nil {
+				h.logger.Error("get tax brackets failed", zap.Error(err))
+				return Result{}, http.StatusInternalServerError, err
+			}
+
+			bracketsByYear[req.TaxYear] = brackets
+		}
+
+		result, err := newCalculator(allowanceSetting, brackets).Calculate(toTax(req))
+		if err != nil {
+			h.logger.Error("tax calculation failed", zap.Error(err))
+			return Result{}, http.StatusBadRequest, err
+		}
+
+		return result, http.StatusOK, nil
+	}
+
+	base, status, err := calculate(req.Base)
+	if err != nil {
+		return c.JSON(status, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	resp := CompareResponse{Base: newResponse(base)}
+	for i, scenarioReq := range scenarios {
+		result, status, err := calculate(scenarioReq)
+		if err != nil {
+			return c.JSON(status, utils.ErrResponse{
+				Error: fmt.Sprintf("scenario %s: %s", req.Scenarios[i].Name, err.Error()),
+			})
+		}
+
+		difference := Compare(base, result)
+		resp.Scenarios = append(resp.Scenarios, ScenarioResponse{
+			Name:   req.Scenarios[i].Name,
+			Result: newResponse(result),
+			Difference: DifferenceResponse{
+				Tax:       difference.Tax,
+				TaxRefund: difference.Refund,
+				NetIncome: difference.NetIncome,
+				TaxLevel:  difference.TaxLevels,
+			},
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h handler) CalculatePenalty(c echo.Context) error {
+	var req PenaltyRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	calculator, _, status, err := h.prepare(req.TaxYear, &req, req.incomeLines())
+	if err != nil {
+		return c.JSON(status, newErrResponse(err))
+	}
+
+	paymentDate, _ := time.Parse(time.DateOnly, req.PaymentDate)
+	penalty, err := calculator.CalculatePenalty(toTax(req.Request), paymentDate)
+	if err != nil {
+		h.logger.Error("penalty calculation failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, PenaltyResponse{
+		Tax:       penalty.Result.Tax,
+		Deadline:  penalty.Deadline.Format(time.DateOnly),
+		Months:    penalty.Months,
+		Surcharge: penalty.Surcharge,
+		Fine:      penalty.Fine,
+		Total:     penalty.Total,
+	})
+}
+
+func (h handler) CalculatePayout(c echo.Context) error {
+	var req PayoutRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("binding request failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.logger.Error("validate request body failed", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
+			Error: utils.GetValidateErrMsg(err),
+		})
+	}
+
+	brackets, err := h.getBrackets(req.TaxYear)
+	if err != nil {
+		h.logger.Error("get tax brackets failed", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+			Error: err.Error(),
+		})
+	}
+
+	result, err := NewCalculator(WithBrackets(brackets)).CalculatePayout(Payout{
+		TaxYear:        req.TaxYear,
+		Severance:      req.Severance,
+		ProvidentFund:  req.ProvidentFund,
+		YearsOfService: req.YearsOfService,
+		LastSalary:     req.LastSalary,
+		Wht:            req.Wht,
+	})
+	if err != nil {
+		h.logger.Error("payout calculation failed", zap.Error(err))
 		return c.JSON(http.StatusBadRequest, utils.ErrResponse{
 			Error: err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, Response{
-		Tax:       taxAmount,
-		TaxLevel:  taxLevels,
-		TaxRefund: refundAmount,
+	return c.JSON(http.StatusOK, PayoutResponse{
+		Tax:           result.Tax,
+		TaxRefund:     nonZero(result.Refund),
+		TaxLevel:      result.TaxLevels,
+		Income:        result.Income,
+		Exemption:     result.Exemption,
+		Expense:       result.Expense,
+		TaxableIncome: result.TaxableIncome,
 	})
 }
 
@@ -155,6 +768,7 @@ func (h handler) UploadCSV(c echo.Context) error {
 	}
 
 	var resp []UploadCSVResponseData
+	bracketsByYear := map[int][]Bracket{}
 	for _, v := range csvData {
 		if err := h.validate.Struct(v); err != nil {
 			h.logger.Error("validate csv record failed", zap.Error(err))
@@ -163,14 +777,24 @@ func (h handler) UploadCSV(c echo.Context) error {
 			})
 		}
 
-		taxAmount, refundAmount, _, err := Calculate(&Tax{
+		brackets, ok := bracketsByYear[v.TaxYear]
+		if !ok {
+			brackets, err = h.getBrackets(v.TaxYear)
+			if err != nil {
+				h.logger.Error("get tax brackets failed", zap.Error(err))
+				return c.JSON(http.StatusInternalServerError, utils.ErrResponse{
+					Error: err.Error(),
+				})
+			}
+
+			bracketsByYear[v.TaxYear] = brackets
+		}
+
+		result, err := newCalculator(allowanceSetting, brackets).Calculate(Tax{
+			TaxYear:    v.TaxYear,
 			Income:     v.TotalIncome,
 			Wht:        v.Wht,
-			Allowances: []Allowance{{donation, v.Donation}},
-			AllowanceSetting: AllowanceSetting{
-				Personal: allowanceSetting.Personal,
-				KReceipt: allowanceSetting.KReceipt,
-			},
+			Allowances: []Allowance{{AllowanceType: donation, Amount: v.Donation}},
 		})
 		if err != nil {
 			h.logger.Error("calculate tax failed", zap.Error(err))
@@ -180,9 +804,15 @@ func (h handler) UploadCSV(c echo.Context) error {
 		}
 
 		resp = append(resp, UploadCSVResponseData{
-			TotalIncome: v.TotalIncome,
-			Tax:         taxAmount,
-			TaxRefund:   refundAmount,
+			TotalIncome:   v.TotalIncome,
+			Tax:           result.Tax,
+			TaxRefund:     nonZero(result.Refund),
+			TaxableIncome: result.TaxableIncome,
+			Deductions:    result.Deductions,
+			EffectiveRate: result.EffectiveRate,
+			MarginalRate:  result.MarginalRate,
+			Bracket:       result.Bracket,
+			NetIncome:     result.NetIncome,
 		})
 	}
 
@@ -190,3 +820,434 @@ func (h handler) UploadCSV(c echo.Context) error {
 		Taxes: resp,
 	})
 }
+
+func (h handler) getBrackets(taxYear int) ([]Bracket, error) {
+	if taxYear == 0 {
+		taxYear = defaultTaxYear
+	}
+
+	rows, err := h.settingRepo.GetBrackets(taxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	brackets := make([]Bracket, 0, len(rows))
+	for _, row := range rows {
+		bracket := Bracket{
+			Lower:        row.Lower,
+			Rate:         row.Rate,
+			NoUpperLimit: row.Upper == nil,
+		}
+		if row.Upper != nil {
+			bracket.Upper = *row.Upper
+		}
+
+		brackets = append(brackets, bracket)
+	}
+
+	return brackets, nil
+}
+
+// incomeLines are the income lines of a request with the withholding and the
+// total income that converting them updates.
+type incomeLines struct {
+	incomes     []IncomeRequest
+	wht         *decimal.Decimal
+	totalIncome *decimal.Decimal
+}
+
+func (r *Request) incomeLines() incomeLines {
+	return incomeLines{incomes: r.Incomes, wht: &r.Wht, totalIncome: &r.TotalIncome}
+}
+
+func (r *FilerRequest) incomeLines() incomeLines {
+	return incomeLines{incomes: r.Incomes, wht: &r.Wht, totalIncome: &r.TotalIncome}
+}
+
+// prepare validates the request body with its income lines converted to THB and
+// returns the calculator for the tax year with the conversions made. When it
+// fails, the status is the one to answer with.
+func (h handler) prepare(taxYear int, body interface{}, lines ...incomeLines) (Calculator, []ConversionResponse, int, error) {
+	conversions, status, err := h.validateRequest(body, lines...)
+	if err != nil {
+		return Calculator{}, nil, status, err
+	}
+
+	allowanceSetting, err := h.settingRepo.Get()
+	if err != nil {
+		h.logger.Error("get allowance setting failed", zap.Error(err))
+		return Calculator{}, nil, http.StatusInternalServerError, err
+	}
+
+	brackets, err := h.getBrackets(taxYear)
+	if err != nil {
+		h.logger.Error("get tax brackets failed", zap.Error(err))
+		return Calculator{}, nil, http.StatusInternalServerError, err
+	}
+
+	return newCalculator(allowanceSetting, brackets), conversions, http.StatusOK, nil
+}
+
+// validateRequest validates and converts the income lines, then validates the
+// body that holds them.
+func (h handler) validateRequest(body interface{}, lines ...incomeLines) ([]ConversionResponse, int, error) {
+	var conversions []ConversionResponse
+	for _, line := range lines {
+		if err := h.validate.Var(line.incomes, "dive"); err != nil {
+			h.logger.Error("validate incomes failed", zap.Error(err))
+			return nil, http.StatusBadRequest, err
+		}
+
+		converted, status, err := h.convertIncomes(line)
+		if err != nil {
+			h.logger.Error("convert currency failed", zap.Error(err))
+			return nil, status, err
+		}
+
+		conversions = append(conversions, converted...)
+	}
+
+	if err := h.validate.Struct(body); err != nil {
+		h.logger.Error("validate request body failed", zap.Error(err))
+		return nil, http.StatusBadRequest, err
+	}
+
+	return conversions, http.StatusOK, nil
+}
+
+// convertIncomes converts the foreign currency income lines to THB at the
+// latest stored rate on or before their date, adds the withholding of every
+// line to wht, sets the total income and returns the conversions made.
+func (h handler) convertIncomes(lines incomeLines) ([]ConversionResponse, int, error) {
+	incomes, wht, totalIncome := lines.incomes, lines.wht, lines.totalIncome
+	var conversions []ConversionResponse
+	for i, income := range incomes {
+		currency := strings.ToUpper(income.Currency)
+		if currency != "" && currency != baseCurrency {
+			if income.Date == "" {
+				return nil, http.StatusBadRequest, errs.ErrIncomeDateRequired
+			}
+
+			date, _ := time.Parse(time.DateOnly, income.Date)
+			rate, err := h.settingRepo.GetExchangeRate(currency, date)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, http.StatusBadRequest, fmt.Errorf("%w: %s %s", errs.ErrExchangeRateNotFound, currency, income.Date)
+			}
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+
+			converted := IncomeRequest{
+				Category: income.Category,
+				Amount:   utils.Round(income.Amount.Mul(rate.Rate), satangPrecision),
+				Expense:  utils.Round(income.Expense.Mul(rate.Rate), satangPrecision),
+				Wht:      utils.Round(income.Wht.Mul(rate.Rate), satangPrecision),
+			}
+			conversions = append(conversions, ConversionResponse{
+				Category:   income.Category,
+				Currency:   currency,
+				Date:       income.Date,
+				Rate:       rate.Rate,
+				Amount:     income.Amount,
+				Expense:    income.Expense,
+				Wht:        income.Wht,
+				THBAmount:  converted.Amount,
+				THBExpense: converted.Expense,
+				THBWht:     converted.Wht,
+			})
+			incomes[i] = converted
+		}
+
+		*wht = wht.Add(incomes[i].Wht)
+		incomes[i].Wht = decimal.Zero
+	}
+
+	if len(incomes) > 0 {
+		*totalIncome = sumIncomes(toIncomes(incomes))
+	}
+
+	return conversions, http.StatusOK, nil
+}
+
+// newErrResponse answers with the invalid fields of a validation error and
+// with the message of any other error.
+func newErrResponse(err error) utils.ErrResponse {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return utils.ErrResponse{Error: utils.GetValidateErrMsg(validationErrs)}
+	}
+
+	return utils.ErrResponse{Error: err.Error()}
+}
+
+// newCalculator returns the Calculator for the admin's allowance setting and
+// the tax year's brackets.
+func newCalculator(allowanceSetting *models.DeductionConfig, brackets []Bracket) Calculator {
+	return NewCalculator(
+		WithAllowanceSetting(toAllowanceSetting(allowanceSetting)),
+		WithBrackets(brackets),
+	)
+}
+
+func toAllowanceSetting(allowanceSetting *models.DeductionConfig) AllowanceSetting {
+	return AllowanceSetting{
+		Personal: allowanceSetting.Personal,
+		KReceipt: allowanceSetting.KReceipt,
+	}
+}
+
+func toTax(req Request) Tax {
+	filingDate, _ := time.Parse(time.DateOnly, req.FilingDate)
+
+	return Tax{
+		TaxYear:          req.TaxYear,
+		Income:           req.TotalIncome,
+		Incomes:          toIncomes(req.Incomes),
+		Wht:              req.Wht,
+		Allowances:       toAllowances(req.Allowances),
+		Dividends:        toDividends(req.Dividends),
+		DividendElection: req.DividendElection,
+		Residency:        req.Residency,
+		DaysInThailand:   req.DaysInThailand,
+		Explain:          req.Explain,
+		FilingDate:       filingDate,
+	}
+}
+
+// applyScenario overlays the fields present in the scenario's override on a
+// copy of the base request and appends the scenario's extra allowances. An
+// override with a field the request does not have is rejected.
+func applyScenario(base Request, scenario ScenarioRequest) (Request, error) {
+	data, err := json.Marshal(base)
+	if err != nil {
+		return Request{}, err
+	}
+
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return Request{}, err
+	}
+
+	if len(scenario.Override) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(scenario.Override))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			return Request{}, err
+		}
+	}
+
+	req.Allowances = append(req.Allowances, scenario.AddAllowances...)
+
+	return req, nil
+}
+
+func toAllowances(allowances []AllowanceRequest) []Allowance {
+	var taxAllowances []Allowance
+	for _, allowance := range allowances {
+		taxAllowances = append(taxAllowances, Allowance{
+			AllowanceType: allowance.AllowanceType,
+			Amount:        allowance.Amount,
+			Category:      allowance.Category,
+			BirthYear:     allowance.BirthYear,
+			Age:           allowance.Age,
+			Income:        allowance.Income,
+		})
+	}
+
+	return taxAllowances
+}
+
+func toIncomes(incomes []IncomeRequest) []Income {
+	var taxIncomes []Income
+	for _, income := range incomes {
+		taxIncomes = append(taxIncomes, Income{
+			Category:      income.Category,
+			Amount:        income.Amount,
+			Expense:       income.Expense,
+			ForeignSource: income.ForeignSource,
+		})
+	}
+
+	return taxIncomes
+}
+
+func toDividends(dividends []DividendRequest) []Dividend {
+	var taxDividends []Dividend
+	for _, dividend := range dividends {
+		taxDividends = append(taxDividends, Dividend{
+			Amount:        dividend.Amount,
+			CorporateRate: dividend.CorporateRate,
+		})
+	}
+
+	return taxDividends
+}
+
+func toFiler(req FilerRequest) Filer {
+	filer := Filer{
+		Income:     req.TotalIncome,
+		Incomes:    toIncomes(req.Incomes),
+		Wht:        req.Wht,
+		Allowances: toAllowances(req.Allowances),
+	}
+	if len(filer.Incomes) > 0 {
+		filer.Income = sumIncomes(filer.Incomes)
+	}
+
+	return filer
+}
+
+func toMonthlyIncomes(incomes []MonthlyIncomeRequest) []MonthlyIncome {
+	var monthlyIncomes []MonthlyIncome
+	for _, income := range incomes {
+		monthlyIncomes = append(monthlyIncomes, MonthlyIncome{
+			Month:  income.Month,
+			Amount: income.Amount,
+		})
+	}
+
+	return monthlyIncomes
+}
+
+func newResponse(result Result) Response {
+	return Response{
+		Tax:           result.Tax,
+		Method:        result.Method,
+		TaxLevel:      result.TaxLevels,
+		TaxRefund:     nonZero(result.Refund),
+		TaxableIncome: result.TaxableIncome,
+		Deductions:    result.Deductions,
+		EffectiveRate: result.EffectiveRate,
+		MarginalRate:  result.MarginalRate,
+		Bracket:       result.Bracket,
+		NetIncome:     result.NetIncome,
+		Incomes:       newIncomeResponses(result.Incomes),
+		Allowances:    newAllowanceResponses(result.Allowances),
+		Explanation:   newStepResponses(result.Steps),
+		Installments:  newInstallmentResponses(result.Installments),
+		Dividends:     newDividendResponse(result.Dividends),
+		Residency:     newResidencyResponse(result.Residency),
+	}
+}
+
+func newIncomeResponses(incomes []IncomeBreakdown) []IncomeResponse {
+	var resp []IncomeResponse
+	for _, income := range incomes {
+		resp = append(resp, IncomeResponse{
+			Category:      income.Category,
+			Amount:        income.Amount,
+			Expense:       income.Expense,
+			ExpenseMethod: income.ExpenseMethod,
+			NetIncome:     income.NetIncome,
+		})
+	}
+
+	return resp
+}
+
+func newAllowanceResponses(allowances []AcceptedAllowance) []AllowanceResponse {
+	resp := make([]AllowanceResponse, 0, len(allowances))
+	for _, allowance := range allowances {
+		var warning string
+		if allowance.Reduction.IsPositive() {
+			warning = fmt.Sprintf("%s reduced by %s to %s, limited by %s", allowance.AllowanceType,
+				utils.FormatNumber(allowance.Reduction), utils.FormatNumber(allowance.Accepted), allowance.LimitedBy)
+		}
+
+		resp = append(resp, AllowanceResponse{
+			AllowanceType: allowance.AllowanceType,
+			Category:      allowance.Category,
+			Amount:        allowance.Amount,
+			Accepted:      allowance.Accepted,
+			Reduction:     nonZero(allowance.Reduction),
+			LimitedBy:     allowance.LimitedBy,
+			Warning:       warning,
+			BirthYear:     allowance.BirthYear,
+			Age:           allowance.Age,
+		})
+	}
+
+	return resp
+}
+
+func newStepResponses(steps []Step) []StepResponse {
+	var resp []StepResponse
+	for _, step := range steps {
+		resp = append(resp, StepResponse{
+			Step:        step.Step,
+			Description: step.Description,
+			Base:        nonZero(step.Base),
+			Rate:        nonZero(step.Rate),
+			Amount:      step.Amount,
+		})
+	}
+
+	return resp
+}
+
+func newInstallmentResponses(installments []Installment) []InstallmentResponse {
+	var resp []InstallmentResponse
+	for _, installment := range installments {
+		resp = append(resp, InstallmentResponse{
+			Installment: installment.Number,
+			DueDate:     installment.DueDate.Format(time.DateOnly),
+			Amount:      installment.Amount,
+		})
+	}
+
+	return resp
+}
+
+func newDividendResponse(dividends DividendTax) *DividendResponse {
+	if dividends.Election == "" {
+		return nil
+	}
+
+	return &DividendResponse{
+		Election:  dividends.Election,
+		Amount:    dividends.Amount,
+		Withheld:  dividends.Withheld,
+		Credit:    dividends.Credit,
+		FinalTax:  dividends.FinalTax,
+		CreditTax: dividends.CreditTax,
+		Cheaper:   dividends.Cheaper,
+		Saving:    dividends.Saving,
+	}
+}
+
+func nonZero(amount decimal.Decimal) *decimal.Decimal {
+	if amount.IsZero() {
+		return nil
+	}
+
+	return &amount
+}
+
+func registerValidations(validate *validator.Validate) {
+	validate.RegisterStructValidation(validateAllowanceRequest, AllowanceRequest{})
+}
+
+func validateAllowanceRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(AllowanceRequest)
+	if req.AllowanceType == "" {
+		return
+	}
+
+	names := allowanceTypes.requestable()
+	if ok := utils.Oneof(req.AllowanceType, names...); !ok {
+		sl.ReportError(req.AllowanceType, "AllowanceType", "AllowanceType", "oneof", strings.Join(names, " "))
+	}
+}
+
+func newResidencyResponse(residency Residency) *ResidencyResponse {
+	if !residency.Declared {
+		return nil
+	}
+
+	return &ResidencyResponse{
+		Status:   residency.Status,
+		Days:     residency.Days,
+		PartYear: residency.PartYear,
+		Rate:     residency.Rate,
+	}
+}
@@ -7,38 +7,1292 @@ import (
 	"github.com/Atvit/assessment-tax/internals/models"
 	mockSetting "github.com/Atvit/assessment-tax/mocks/setting"
 	"github.com/Atvit/assessment-tax/utils"
-	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 )
 
+func TestMain(m *testing.M) {
+	// the server writes amounts to JSON as numbers, see main
+	decimal.MarshalJSONWithoutQuotes = true
+	os.Exit(m.Run())
+}
+
 func TestHandler_CalculateTax(t *testing.T) {
 	type testcase struct {
-		requestBody     []byte
-		expectedStatus  int
-		expectedBody    string
-		mockCalculateFn func(t *Tax) (float64, float64, []TaxLevel, error)
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	registerValidations(validate)
+
+	t.Run("invalid request", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`[]`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"code=400, message=Unmarshal type error: expected=tax.Request, got=array, field=, offset=1, internal=json: cannot unmarshal array into Go value of type tax.Request"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("valid request", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 0.0}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":29000,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":29000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxableIncome":440000,"deductions":60000,"effectiveRate":0.058,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":471000,"allowances":[{"allowanceType":"donation","amount":0,"accepted":0},{"allowanceType":"personal","amount":60000,"accepted":60000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("k-receipt allowance", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.0,"wht": 0.0,"allowances": [{"allowanceType": "k-receipt","amount": 200000.0},{"allowanceType": "donation","amount": 100000.0}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":20100,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":20100},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxableIncome":351000,"deductions":149000,"effectiveRate":0.0402,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":479900,"allowances":[{"allowanceType":"k-receipt","amount":200000,"accepted":50000,"reduction":150000,"limitedBy":"k-receipt-max","warning":"k-receipt reduced by 150,000 to 50,000, limited by k-receipt-max"},{"allowanceType":"donation","amount":100000,"accepted":39000,"reduction":61000,"limitedBy":"donation-net-income-rate","warning":"donation reduced by 61,000 to 39,000, limited by donation-net-income-rate"},{"allowanceType":"personal","amount":60000,"accepted":60000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("invalid totalIncome", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": -100, "wht": 0}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"TotalIncome","message":"the value of TotalIncome must be greater than or equal 0"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("invalid allowance type", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000, "allowances": [{"allowanceType": "personal", "amount": 100000}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"AllowanceType","message":"the value of AllowanceType must be one of donation k-receipt spouse child parent life-insurance health-insurance parent-health-insurance annuity-insurance provident-fund rmf ssf thai-esg"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("invalid WHT greater than totalIncome", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 5000, "wht": 6000}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"Wht","message":"the value of Wht value must be lower than or equal value of field TotalIncome"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("tax calculation error", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 50000, "taxYear": 2500}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"unsupported tax year: 2500"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("exact decimal amounts", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.55, "wht": "0.15"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":28999.95,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":29000.1},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxableIncome":440000.55,"deductions":60000,"effectiveRate":0.058,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":471000.45,"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"tax":28999.95,`)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("unsupported tax year", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2500, "totalIncome": 500000.0, "wht": 0.0}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"unsupported tax year: 2500"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("stored tax brackets", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.0, "wht": 0.0}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":48000,"method":"progressive","taxLevel":[{"level":"0-200,000","tax":0},{"level":"200,001 ขึ้นไป","tax":48000}],"taxableIncome":440000,"deductions":60000,"effectiveRate":0.096,"marginalRate":0.2,"bracket":"200,001 ขึ้นไป","netIncome":452000,"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", 2567).Return([]models.TaxBracket{
+			{TaxYear: 2567, Lower: decimal.Zero, Upper: utils.ToPointer(decimal.NewFromInt(200000)), Rate: decimal.Zero},
+			{TaxYear: 2567, Lower: decimal.NewFromInt(200000), Rate: decimal.NewFromFloat(0.2)},
+		}, nil).Once()
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("family allowances", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 800000.0, "wht": 0.0, "allowances": [{"allowanceType": "spouse"}, {"allowanceType": "child", "birthYear": 2560}, {"allowanceType": "child", "birthYear": 2563}, {"allowanceType": "parent", "age": 65, "amount": 40000}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":44000,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":35000},{"level":"500,001-1,000,000","tax":9000},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxableIncome":560000,"deductions":240000,"effectiveRate":0.055,"marginalRate":0.15,"bracket":"500,001-1,000,000","netIncome":756000,"allowances":[{"allowanceType":"spouse","amount":0,"accepted":60000},{"allowanceType":"child","amount":0,"accepted":30000,"birthYear":2560},{"allowanceType":"child","amount":0,"accepted":60000,"birthYear":2563},{"allowanceType":"parent","amount":40000,"accepted":30000,"reduction":10000,"limitedBy":"parent-max","warning":"parent reduced by 10,000 to 30,000, limited by parent-max","age":65},{"allowanceType":"personal","amount":60000,"accepted":60000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("political donation", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "category": "political", "amount": 20000.0}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":28000,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":28000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxableIncome":430000,"deductions":70000,"effectiveRate":0.056,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":472000,"allowances":[{"allowanceType":"donation","category":"political","amount":20000,"accepted":10000,"reduction":10000,"limitedBy":"donation-political-max","warning":"donation reduced by 10,000 to 10,000, limited by donation-political-max"},{"allowanceType":"personal","amount":60000,"accepted":60000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("categorized incomes", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"incomes": [{"category": "40(1)", "amount": 600000.0}, {"category": "40(8)", "amount": 500000.0, "expense": 200000.0}], "wht": 50000.0}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":6000,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":35000},{"level":"500,001-1,000,000","tax":21000},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxableIncome":640000,"deductions":460000,"effectiveRate":0.0509,"marginalRate":0.15,"bracket":"500,001-1,000,000","netIncome":1044000,"incomes":[{"category":"40(1)","amount":600000,"expense":100000,"expenseMethod":"standard","netIncome":500000},{"category":"40(8)","amount":500000,"expense":300000,"expenseMethod":"standard","netIncome":200000}],"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("explain calculation", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.0, "wht": 25000.0, "allowances": [{"allowanceType": "k-receipt", "amount": 100000.0}], "explain": true}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":24000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxRefund":1000,"taxableIncome":390000,"deductions":110000,"effectiveRate":0.048,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":476000,` +
+				`"allowances":[{"allowanceType":"k-receipt","amount":100000,"accepted":50000,"reduction":50000,"limitedBy":"k-receipt-max","warning":"k-receipt reduced by 50,000 to 50,000, limited by k-receipt-max"},{"allowanceType":"personal","amount":60000,"accepted":60000}],` +
+				`"explanation":[{"step":"income","description":"total income","amount":500000},` +
+				`{"step":"automatic-allowance","description":"personal allowance added","amount":60000},` +
+				`{"step":"allowance","description":"k-receipt limited by k-receipt-max","base":100000,"amount":50000},` +
+				`{"step":"allowance","description":"personal","base":60000,"amount":60000},` +
+				`{"step":"taxable-income","description":"income - expenses - allowances","base":500000,"amount":390000},` +
+				`{"step":"bracket","description":"0-150,000","base":150000,"amount":0},` +
+				`{"step":"bracket","description":"150,001-500,000","base":240000,"rate":0.1,"amount":24000},` +
+				`{"step":"progressive-tax","description":"sum of bracket tax","amount":24000},` +
+				`{"step":"tax-method","description":"progressive","amount":24000},` +
+				`{"step":"wht","description":"withholding tax offset","base":24000,"amount":25000},` +
+				`{"step":"tax","description":"tax payable","amount":0},` +
+				`{"step":"refund","description":"tax refund","amount":1000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("installment plan", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2567, "totalIncome": 500000.0, "filingDate": "2025-03-01"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"tax":29000,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":29000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxableIncome":440000,"deductions":60000,"effectiveRate":0.058,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":471000,` +
+				`"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}],` +
+				`"installments":[{"installment":1,"dueDate":"2025-03-01","amount":9666.7},{"installment":2,"dueDate":"2025-04-30","amount":9666.7},{"installment":3,"dueDate":"2025-05-31","amount":9666.6}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid filing date", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.0, "filingDate": "01/03/2025"}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"FilingDate","message":"the value of FilingDate must be a date in format 2006-01-02"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("dividend tax credit", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2567, "totalIncome": 200000.0, "dividends": [{"amount": 100000.0, "corporateRate": 0.2}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":11500},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxRefund":23500,"taxableIncome":265000,"deductions":60000,"effectiveRate":-0.045,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":313500,` +
+				`"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}],` +
+				`"dividends":{"election":"credit","amount":100000,"withheld":10000,"credit":25000,"finalTax":10000,"creditTax":-13500,"cheaper":"credit","saving":23500}}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid corporate rate", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 200000.0, "dividends": [{"amount": 100000.0, "corporateRate": 1}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"CorporateRate","message":"the value of CorporateRate must be less than 1"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("non-resident with foreign income", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2567, "incomes": [{"category": "40(1)", "amount": 600000.0}, {"category": "40(1)", "amount": 300000.0, "foreignSource": true}], "allowances": [{"allowanceType": "spouse", "amount": 60000.0}], "residency": "non-resident", "daysInThailand": 90}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"tax":33524.6,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":33524.6},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxableIncome":485246,"deductions":114754,"effectiveRate":0.0559,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":566475.4,` +
+				`"incomes":[{"category":"40(1)","amount":600000,"expense":100000,"expenseMethod":"standard","netIncome":500000}],` +
+				`"allowances":[{"allowanceType":"spouse","amount":60000,"accepted":0,"reduction":60000,"limitedBy":"spouse-non-resident","warning":"spouse reduced by 60,000 to 0, limited by spouse-non-resident"},` +
+				`{"allowanceType":"personal","amount":60000,"accepted":14754,"reduction":45246,"limitedBy":"personal-residency-prorated","warning":"personal reduced by 45,246 to 14,754, limited by personal-residency-prorated"}],` +
+				`"residency":{"status":"non-resident","days":90,"partYear":false,"rate":0.2459}}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("residency does not match days in Thailand", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2567, "totalIncome": 500000.0, "residency": "resident", "daysInThailand": 90}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"residency does not match days in Thailand"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("foreign currency income", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2567, "totalIncome": 0.0, "incomes": [{"category": "40(1)", "amount": 300000.0, "wht": 5000.0}, {"category": "40(2)", "amount": 10000.0, "wht": 300.0, "currency": "usd", "date": "2024-05-15"}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"tax":19860,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":35000},{"level":"500,001-1,000,000","tax":825},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxableIncome":505500,"deductions":160000,"effectiveRate":0.0538,"marginalRate":0.15,"bracket":"500,001-1,000,000","netIncome":629675,` +
+				`"incomes":[{"category":"40(1)","amount":300000,"expense":100000,"expenseMethod":"standard","netIncome":200000},{"category":"40(2)","amount":365500,"expense":0,"expenseMethod":"standard","netIncome":365500}],` +
+				`"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}],` +
+				`"conversions":[{"category":"40(2)","currency":"USD","date":"2024-05-15","rate":36.55,"amount":10000,"expense":0,"wht":300,"thbAmount":365500,"thbExpense":0,"thbWht":10965}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+		settingRepo.On("GetExchangeRate", "USD", time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)).
+			Return(&models.ExchangeRate{Currency: "USD", Date: time.Date(2024, time.May, 14, 0, 0, 0, 0, time.UTC), Rate: decimal.RequireFromString("36.55")}, nil).Once()
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("withholding checked against converted income", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2567, "totalIncome": 0.0, "wht": 50000.0, "incomes": [{"category": "40(2)", "amount": 10000.01, "currency": "USD", "date": "2024-05-15"}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":5550},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxRefund":44450,"taxableIncome":205500.37,"deductions":160000,"effectiveRate":0.0152,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":359950.37,` +
+				`"incomes":[{"category":"40(2)","amount":365500.37,"expense":100000,"expenseMethod":"standard","netIncome":265500.37}],` +
+				`"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}],` +
+				`"conversions":[{"category":"40(2)","currency":"USD","date":"2024-05-15","rate":36.55,"amount":10000.01,"expense":0,"wht":0,"thbAmount":365500.37,"thbExpense":0,"thbWht":0}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+		settingRepo.On("GetExchangeRate", "USD", time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)).
+			Return(&models.ExchangeRate{Currency: "USD", Date: time.Date(2024, time.May, 14, 0, 0, 0, 0, time.UTC), Rate: decimal.RequireFromString("36.55")}, nil).Once()
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid income currency", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 0.0, "incomes": [{"category": "40(2)", "amount": 10000.0, "currency": "US", "date": "2024-05-15"}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"Currency","message":"the length of Currency must be 3"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("exchange rate not found", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 0.0, "incomes": [{"category": "40(2)", "amount": 10000.0, "currency": "EUR", "date": "2024-05-15"}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"exchange rate not found: EUR 2024-05-15"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+		settingRepo.On("GetExchangeRate", "EUR", mock.AnythingOfType("time.Time")).Return(nil, sql.ErrNoRows).Once()
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("foreign currency income without date", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 0.0, "incomes": [{"category": "40(2)", "amount": 10000.0, "currency": "EUR"}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"foreign currency income requires a date"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("incorrect income category", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"incomes": [{"category": "40(9)", "amount": 600000.0}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"incorrect income category"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("parent under age", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 800000.0, "allowances": [{"allowanceType": "parent", "age": 55}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"parent must be at least 60 years old"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("get tax brackets failed", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.0, "wht": 0.0}`),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"sql: connection is already closed"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", 2567).Return(nil, sql.ErrConnDone).Once()
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("return tax refund field", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 150000.0, "wht": 10000.0, "allowances": [{"allowanceType": "donation", "amount": 200000.0}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":0},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxRefund":10000,"taxableIncome":81000,"deductions":69000,"effectiveRate":0,"marginalRate":0,"bracket":"0-150,000","netIncome":150000,"allowances":[{"allowanceType":"donation","amount":200000,"accepted":9000,"reduction":191000,"limitedBy":"donation-net-income-rate","warning":"donation reduced by 191,000 to 9,000, limited by donation-net-income-rate"},{"allowanceType":"personal","amount":60000,"accepted":60000}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+
+	t.Run("get tax setting failed", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 150000.0, "wht": 10000.0, "allowances": [{"allowanceType": "donation", "amount": 200000.0}]}`),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"sql: no rows in result set"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		errNoRows := sql.ErrNoRows
+		settingRepo.On("Get").Return(nil, errNoRows).Once()
+
+		if assert.NoError(t, h.CalculateTax(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+		}
+	})
+}
+
+func TestHandler_GoalSeek(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	registerValidations(validate)
+
+	t.Run("target tax", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"target": "tax", "amount": 29000.0}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"totalIncome":499999.5,"netIncome":470999.5,"tax":29000}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/goal-seek", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.GoalSeek(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("target refund", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"target": "refund", "amount": 10000.0, "wht": 50000.0}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"totalIncome":593333,"netIncome":553333,"tax":0,"taxRefund":10000}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/goal-seek", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.GoalSeek(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"target": "income", "amount": 29000.0}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"Target","message":"the value of Target must be one of tax netIncome refund"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/goal-seek", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.GoalSeek(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("get setting failed", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"target": "tax", "amount": 29000.0}`),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"sql: no rows in result set"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/goal-seek", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(nil, sql.ErrNoRows).Once()
+
+		if assert.NoError(t, h.GoalSeek(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("goal unreachable", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"target": "refund", "amount": 60000.0, "wht": 50000.0}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"goal cannot be reached"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/goal-seek", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.GoalSeek(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestHandler_Advise(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	registerValidations(validate)
+
+	t.Run("advice with budget", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 1000000.0, "budget": 100000.0}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"tax":101000,"marginalRate":0.15,"taxSavedPerBaht":0.15,"headroom":[` +
+				`{"allowanceType":"k-receipt","amount":50000},{"allowanceType":"life-insurance","amount":100000},` +
+				`{"allowanceType":"health-insurance","amount":25000},{"allowanceType":"parent-health-insurance","amount":15000},` +
+				`{"allowanceType":"annuity-insurance","amount":150000},{"allowanceType":"provident-fund","amount":150000},` +
+				`{"allowanceType":"rmf","amount":300000},{"allowanceType":"ssf","amount":200000},` +
+				`{"allowanceType":"thai-esg","amount":300000},{"allowanceType":"donation","amount":94000}],` +
+				`"suggestions":[{"allowanceType":"k-receipt","amount":50000,"taxSaved":7500,"message":"you can save 7,500 by buying 50,000 of k-receipt"},` +
+				`{"allowanceType":"life-insurance","amount":50000,"taxSaved":7500,"message":"you can save 7,500 by buying 50,000 of life-insurance"}],` +
+				`"taxSaved":15000}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/advice", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.Advise(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid budget", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 1000000.0, "budget": -1.0}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"Budget","message":"the value of Budget must be greater than or equal 0"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/advice", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.Advise(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("get tax brackets failed", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 1000000.0, "budget": 100000.0}`),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"sql: connection is already closed"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/advice", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return(nil, sql.ErrConnDone).Once()
+
+		if assert.NoError(t, h.Advise(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestHandler_ProjectWithholding(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	registerValidations(validate)
+
+	t.Run("salary with bonus", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"salary": 50000.0, "bonuses": [{"month": 3, "amount": 100000.0}]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"annualIncome":700000,"annualTax":41000,"totalWithholding":41000,"overWithheld":0,"months":[` +
+				`{"month":1,"salary":50000,"bonus":0,"withholding":2416.7},` +
+				`{"month":2,"salary":50000,"bonus":0,"withholding":2416.7},` +
+				`{"month":3,"salary":50000,"bonus":100000,"withholding":14416.7},` +
+				`{"month":4,"salary":50000,"bonus":0,"withholding":2416.7},` +
+				`{"month":5,"salary":50000,"bonus":0,"withholding":2416.7},` +
+				`{"month":6,"salary":50000,"bonus":0,"withholding":2416.6},` +
+				`{"month":7,"salary":50000,"bonus":0,"withholding":2416.7},` +
+				`{"month":8,"salary":50000,"bonus":0,"withholding":2416.6},` +
+				`{"month":9,"salary":50000,"bonus":0,"withholding":2416.7},` +
+				`{"month":10,"salary":50000,"bonus":0,"withholding":2416.6},` +
+				`{"month":11,"salary":50000,"bonus":0,"withholding":2416.7},` +
+				`{"month":12,"salary":50000,"bonus":0,"withholding":2416.6}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/withholding", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.ProjectWithholding(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid bonus month", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"salary": 50000.0, "bonuses": [{"month": 13, "amount": 100000.0}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"Month","message":"the value of Month must be less than or equal 12"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/withholding", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.ProjectWithholding(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("get setting failed", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"salary": 50000.0}`),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"sql: no rows in result set"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/withholding", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(nil, sql.ErrNoRows).Once()
+
+		if assert.NoError(t, h.ProjectWithholding(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestHandler_CompareFiling(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
 	}
 
 	e := echo.New()
 	logger := zap.NewNop()
-	validate := validator.New()
+	validate := utils.NewValidator()
+	registerValidations(validate)
 
-	t.Run("invalid request", func(t *testing.T) {
+	t.Run("joint filing is cheaper", func(t *testing.T) {
 		tc := testcase{
-			requestBody:     []byte(`[]`),
-			mockCalculateFn: nil,
-			expectedStatus:  http.StatusBadRequest,
-			expectedBody:    `{"error":"code=400, message=Unmarshal type error: expected=tax.Request, got=array, field=, offset=1, internal=json: cannot unmarshal array into Go value of type tax.Request"}`,
+			requestBody:    []byte(`{"filer": {"totalIncome": 500000.0, "wht": 30000.0}, "spouse": {"totalIncome": 100000.0, "allowances": [{"allowanceType": "k-receipt", "amount": 50000.0}]}}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"joint":{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":28000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxRefund":2000,` +
+				`"taxableIncome":430000,"deductions":170000,"effectiveRate":0.0467,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":572000,` +
+				`"allowances":[{"allowanceType":"k-receipt","amount":50000,"accepted":50000},{"allowanceType":"personal","amount":0,"accepted":60000},{"allowanceType":"personal","amount":60000,"accepted":60000}]},` +
+				`"separate":{"filer":{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":29000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxRefund":1000,` +
+				`"taxableIncome":440000,"deductions":60000,"effectiveRate":0.058,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":471000,` +
+				`"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}]},` +
+				`"spouse":{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":0},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxableIncome":0,"deductions":110000,"effectiveRate":0,"marginalRate":0,"bracket":"0-150,000","netIncome":100000,` +
+				`"allowances":[{"allowanceType":"k-receipt","amount":50000,"accepted":50000},{"allowanceType":"personal","amount":60000,"accepted":60000}]}},` +
+				`"jointTax":-2000,"separateTax":-1000,"recommendation":"joint","saving":1000}`,
 		}
 
-		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/filing-comparison", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CompareFiling(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("invalid spouse income", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"filer": {"totalIncome": 500000.0}, "spouse": {"totalIncome": -1.0}}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"TotalIncome","message":"the value of TotalIncome must be greater than or equal 0"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/filing-comparison", bytes.NewReader(tc.requestBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -48,21 +1302,32 @@ func TestHandler_CalculateTax(t *testing.T) {
 			validate: validate,
 		}
 
-		if assert.NoError(t, h.CalculateTax(c)) {
+		if assert.NoError(t, h.CompareFiling(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
 		}
 	})
 
-	t.Run("valid request", func(t *testing.T) {
+	t.Run("foreign currency spouse income", func(t *testing.T) {
 		tc := testcase{
-			requestBody:     []byte(`{"totalIncome": 500000.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 0.0}]}`),
-			mockCalculateFn: func(t *Tax) (float64, float64, []TaxLevel, error) { return 29000, 0, getMockTaxLevels(), nil },
-			expectedStatus:  http.StatusOK,
-			expectedBody:    `{"tax":29000,"taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":0},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}]}`,
+			requestBody:    []byte(`{"filer": {"incomes": [{"category": "40(1)", "amount": 500000.0}]}, "spouse": {"incomes": [{"category": "40(2)", "amount": 10000.0, "wht": 300.0, "currency": "USD", "date": "2024-05-15"}]}}`),
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"joint":{"tax":30860,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":35000},{"level":"500,001-1,000,000","tax":6825},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxableIncome":545500,"deductions":320000,"effectiveRate":0.0483,"marginalRate":0.15,"bracket":"500,001-1,000,000","netIncome":823675,` +
+				`"incomes":[{"category":"40(1)","amount":500000,"expense":100000,"expenseMethod":"standard","netIncome":400000},{"category":"40(2)","amount":365500,"expense":100000,"expenseMethod":"standard","netIncome":265500}],` +
+				`"allowances":[{"allowanceType":"personal","amount":0,"accepted":60000},{"allowanceType":"personal","amount":60000,"accepted":60000}]},` +
+				`"separate":{"filer":{"tax":19000,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":19000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxableIncome":340000,"deductions":160000,"effectiveRate":0.038,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":481000,` +
+				`"incomes":[{"category":"40(1)","amount":500000,"expense":100000,"expenseMethod":"standard","netIncome":400000}],` +
+				`"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}]},` +
+				`"spouse":{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":5550},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxRefund":5415,` +
+				`"taxableIncome":205500,"deductions":160000,"effectiveRate":0.0152,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":359950,` +
+				`"incomes":[{"category":"40(2)","amount":365500,"expense":100000,"expenseMethod":"standard","netIncome":265500}],` +
+				`"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}]}},` +
+				`"jointTax":30860,"separateTax":13585,"recommendation":"separate","saving":17275}`,
 		}
 
-		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/filing-comparison", bytes.NewReader(tc.requestBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -74,29 +1339,72 @@ func TestHandler_CalculateTax(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		originalCalculate := Calculate
-		Calculate = tc.mockCalculateFn
-		defer func() { Calculate = originalCalculate }()
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+		settingRepo.On("GetExchangeRate", "USD", time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)).
+			Return(&models.ExchangeRate{Currency: "USD", Date: time.Date(2024, time.May, 14, 0, 0, 0, 0, time.UTC), Rate: decimal.RequireFromString("36.55")}, nil).Once()
+
+		if assert.NoError(t, h.CompareFiling(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("mixed total and categorized incomes", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"filer": {"incomes": [{"category": "40(1)", "amount": 500000.0}]}, "spouse": {"totalIncome": 100000.0}}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"both filers must give either total income or categorized incomes"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/filing-comparison", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
 
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
 
-		if assert.NoError(t, h.CalculateTax(c)) {
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CompareFiling(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
 		}
 	})
+}
 
-	t.Run("k-receipt allowance", func(t *testing.T) {
+func TestHandler_CompareScenarios(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	registerValidations(validate)
+
+	t.Run("scenario with override and extra allowance", func(t *testing.T) {
 		tc := testcase{
-			requestBody: []byte(`{"totalIncome": 500000.0,"wht": 0.0,"allowances": [{"allowanceType": "k-receipt","amount": 200000.0},{"allowanceType": "donation","amount": 100000.0}]}`),
-			mockCalculateFn: func(t *Tax) (float64, float64, []TaxLevel, error) {
-				return 14000, 0, getMockTaxLevels(TaxLevel{level2, utils.ToPointer(14000.0)}), nil
-			},
+			requestBody:    []byte(`{"base": {"totalIncome": 500000.0}, "scenarios": [{"name": "extra donation and wht", "override": {"wht": 30000.0}, "addAllowances": [{"allowanceType": "donation", "amount": 10000.0}]}]}`),
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"tax":14000,"taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":14000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}]}`,
+			expectedBody: `{"base":{"tax":29000,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":29000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],` +
+				`"taxableIncome":440000,"deductions":60000,"effectiveRate":0.058,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":471000,` +
+				`"allowances":[{"allowanceType":"personal","amount":60000,"accepted":60000}]},` +
+				`"scenarios":[{"name":"extra donation and wht","result":{"tax":0,"method":"progressive","taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":28000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxRefund":2000,` +
+				`"taxableIncome":430000,"deductions":70000,"effectiveRate":0.056,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":472000,` +
+				`"allowances":[{"allowanceType":"donation","amount":10000,"accepted":10000},{"allowanceType":"personal","amount":60000,"accepted":60000}]},` +
+				`"difference":{"tax":-29000,"taxRefund":2000,"netIncome":1000,"taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":-1000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}]}}]}`,
 		}
 
-		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/compare", bytes.NewReader(tc.requestBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -108,27 +1416,24 @@ func TestHandler_CalculateTax(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		originalCalculate := Calculate
-		Calculate = tc.mockCalculateFn
-		defer func() { Calculate = originalCalculate }()
-
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
 
-		if assert.NoError(t, h.CalculateTax(c)) {
+		if assert.NoError(t, h.CompareScenarios(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+			settingRepo.AssertNumberOfCalls(t, "Get", 1)
 		}
 	})
 
-	t.Run("invalid totalIncome", func(t *testing.T) {
+	t.Run("invalid scenario", func(t *testing.T) {
 		tc := testcase{
-			requestBody:     []byte(`{"totalIncome": -100, "wht": 0}`),
-			mockCalculateFn: nil,
-			expectedStatus:  http.StatusBadRequest,
-			expectedBody:    `{"error":[{"field":"TotalIncome","message":"the value of TotalIncome must be greater than or equal 0"}]}`,
+			requestBody:    []byte(`{"base": {"totalIncome": 500000.0}, "scenarios": [{"name": "negative wht", "override": {"wht": -1.0}}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"Wht","message":"the value of Wht must be greater than or equal 0"}]}`,
 		}
 
-		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/compare", bytes.NewReader(tc.requestBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -138,21 +1443,20 @@ func TestHandler_CalculateTax(t *testing.T) {
 			validate: validate,
 		}
 
-		if assert.NoError(t, h.CalculateTax(c)) {
+		if assert.NoError(t, h.CompareScenarios(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
 		}
 	})
 
-	t.Run("invalid WHT greater than totalIncome", func(t *testing.T) {
+	t.Run("unknown override field", func(t *testing.T) {
 		tc := testcase{
-			requestBody:     []byte(`{"totalIncome": 5000, "wht": 6000}`),
-			mockCalculateFn: nil,
-			expectedStatus:  http.StatusBadRequest,
-			expectedBody:    `{"error":[{"field":"Wht","message":"the value of Wht value must be lower than or equal value of field TotalIncome"}]}`,
+			requestBody:    []byte(`{"base": {"totalIncome": 500000.0}, "scenarios": [{"name": "typo", "override": {"whtt": 30000.0}}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"scenario typo: json: unknown field \"whtt\""}`,
 		}
 
-		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/compare", bytes.NewReader(tc.requestBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -162,21 +1466,20 @@ func TestHandler_CalculateTax(t *testing.T) {
 			validate: validate,
 		}
 
-		if assert.NoError(t, h.CalculateTax(c)) {
+		if assert.NoError(t, h.CompareScenarios(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
 		}
 	})
 
-	t.Run("tax calculation error", func(t *testing.T) {
+	t.Run("unsupported scenario tax year", func(t *testing.T) {
 		tc := testcase{
-			requestBody:     []byte(`{"totalIncome": 50000}`),
-			mockCalculateFn: func(t *Tax) (float64, float64, []TaxLevel, error) { return 0, 0, nil, errors.New("calculation error") },
-			expectedStatus:  http.StatusBadRequest,
-			expectedBody:    `{"error":"calculation error"}`,
+			requestBody:    []byte(`{"base": {"totalIncome": 500000.0}, "scenarios": [{"name": "next year", "override": {"taxYear": 2570}}]}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"scenario next year: unsupported tax year: 2570"}`,
 		}
 
-		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/compare", bytes.NewReader(tc.requestBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -188,33 +1491,128 @@ func TestHandler_CalculateTax(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		originalCalculate := Calculate
-		Calculate = tc.mockCalculateFn
-		defer func() { Calculate = originalCalculate }()
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
 
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+		if assert.NoError(t, h.CompareScenarios(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
 
-		if assert.NoError(t, h.CalculateTax(c)) {
+func TestHandler_CalculatePenalty(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	registerValidations(validate)
+
+	t.Run("late filing and payment", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2567, "totalIncome": 500000.0, "paymentDate": "2025-05-01"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"tax":29000,"deadline":"2025-03-31","months":2,"surcharge":870,"fine":200,"total":30070}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/penalty", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculatePenalty(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
 		}
 	})
 
-	t.Run("return tax refund field", func(t *testing.T) {
+	t.Run("missing payment date", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"totalIncome": 500000.0}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"PaymentDate","message":"field PaymentDate is required"}]}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/penalty", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculatePenalty(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+
+	t.Run("unsupported tax year", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"taxYear": 2570, "totalIncome": 500000.0, "paymentDate": "2025-05-01"}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"unsupported tax year: 2570"}`,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/penalty", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculatePenalty(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
+}
+
+func TestHandler_CalculatePayout(t *testing.T) {
+	type testcase struct {
+		requestBody    []byte
+		expectedStatus int
+		expectedBody   string
+	}
+
+	e := echo.New()
+	logger := zap.NewNop()
+	validate := utils.NewValidator()
+	registerValidations(validate)
+
+	t.Run("severance taxed separately", func(t *testing.T) {
 		tc := testcase{
-			requestBody: []byte(`{"totalIncome": 150000.0, "wht": 10000.0, "allowances": [{"allowanceType": "donation", "amount": 200000.0}]}`),
-			mockCalculateFn: func(t *Tax) (float64, float64, []TaxLevel, error) {
-				return 0, 10000, getMockTaxLevels(
-					TaxLevel{level2, utils.ToPointer(35000.0)},
-					TaxLevel{level3, utils.ToPointer(75000.0)},
-					TaxLevel{level4, utils.ToPointer(68000.0)},
-				), nil
-			},
+			requestBody:    []byte(`{"taxYear": 2567, "severance": 1000000.0, "yearsOfService": 20, "lastSalary": 50000.0}`),
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"tax":0,"taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":35000},{"level":"500,001-1,000,000","tax":75000},{"level":"1,000,001-2,000,000","tax":68000},{"level":"2,000,001 ขึ้นไป","tax":0}],"taxRefund":10000}`,
+			expectedBody:   `{"tax":3000,"taxLevel":[{"level":"0-150,000","tax":0},{"level":"150,001-500,000","tax":3000},{"level":"500,001-1,000,000","tax":0},{"level":"1,000,001-2,000,000","tax":0},{"level":"2,000,001 ขึ้นไป","tax":0}],"income":500000,"exemption":500000,"expense":140000,"taxableIncome":180000}`,
 		}
 
-		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/payout", bytes.NewReader(tc.requestBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -226,29 +1624,45 @@ func TestHandler_CalculateTax(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		originalCalculate := Calculate
-		Calculate = tc.mockCalculateFn
-		defer func() { Calculate = originalCalculate }()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if assert.NoError(t, h.CalculatePayout(c)) {
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		}
+	})
 
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+	t.Run("missing years of service", func(t *testing.T) {
+		tc := testcase{
+			requestBody:    []byte(`{"severance": 1000000.0}`),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":[{"field":"YearsOfService","message":"field YearsOfService is required"}]}`,
+		}
 
-		if assert.NoError(t, h.CalculateTax(c)) {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/payout", bytes.NewReader(tc.requestBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := &handler{
+			logger:   logger,
+			validate: validate,
+		}
+
+		if assert.NoError(t, h.CalculatePayout(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
 		}
 	})
 
-	t.Run("get tax setting failed", func(t *testing.T) {
+	t.Run("get tax brackets failed", func(t *testing.T) {
 		tc := testcase{
-			requestBody: []byte(`{"totalIncome": 150000.0, "wht": 10000.0, "allowances": [{"allowanceType": "donation", "amount": 200000.0}]}`),
-			mockCalculateFn: func(t *Tax) (float64, float64, []TaxLevel, error) {
-				return 0, 10000, getMockTaxLevels(), nil
-			},
+			requestBody:    []byte(`{"severance": 1000000.0, "yearsOfService": 20}`),
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"error":"sql: no rows in result set"}`,
+			expectedBody:   `{"error":"database error"}`,
 		}
 
-		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.requestBody))
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/payout", bytes.NewReader(tc.requestBody))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -260,39 +1674,75 @@ func TestHandler_CalculateTax(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		originalCalculate := Calculate
-		Calculate = tc.mockCalculateFn
-		defer func() { Calculate = originalCalculate }()
-
-		errNoRows := sql.ErrNoRows
-		settingRepo.On("Get").Return(nil, errNoRows).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return(nil, errors.New("database error"))
 
-		if assert.NoError(t, h.CalculateTax(c)) {
+		if assert.NoError(t, h.CalculatePayout(c)) {
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			assert.JSONEq(t, rec.Body.String(), tc.expectedBody)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
 		}
 	})
 }
 
 func TestHandler_UploadCSV(t *testing.T) {
 	type testcase struct {
-		fileContent     string
-		mockReadError   error
-		expectedStatus  int
-		expectedBody    string
-		mockCalculateFn func(t *Tax) (float64, float64, []TaxLevel, error)
+		fileContent    string
+		mockReadError  error
+		expectedStatus int
+		expectedBody   string
 	}
 
 	e := echo.New()
 	logger := zap.NewNop()
-	validate := validator.New()
+	validate := utils.NewValidator()
+	registerValidations(validate)
 
 	t.Run("valid CSV file", func(t *testing.T) {
 		tc := testcase{
 			fileContent:    "totalIncome,wht,donation\n500000,0,0\n600000,40000,20000\n750000,50000,15000",
 			mockReadError:  nil,
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"taxes":[{"totalIncome":500000,"tax":29000},{"totalIncome":600000,"tax":0,"taxRefund":2000},{"totalIncome":750000,"tax":11250}]}`,
+			expectedBody: `{"taxes":[` +
+				`{"totalIncome":500000,"tax":29000,"taxableIncome":440000,"deductions":60000,"effectiveRate":0.058,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":471000},` +
+				`{"totalIncome":600000,"tax":0,"taxRefund":2000,"taxableIncome":520000,"deductions":80000,"effectiveRate":0.0633,"marginalRate":0.15,"bracket":"500,001-1,000,000","netIncome":562000},` +
+				`{"totalIncome":750000,"tax":11250,"taxableIncome":675000,"deductions":75000,"effectiveRate":0.0817,"marginalRate":0.15,"bracket":"500,001-1,000,000","netIncome":688750}]}`,
+		}
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("taxFile", "taxes.csv")
+		part.Write([]byte(tc.fileContent))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/upload-csv", body)
+		req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		settingRepo := new(mockSetting.Repository)
+
+		h := &handler{
+			logger:      logger,
+			validate:    validate,
+			settingRepo: settingRepo,
+		}
+
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
+
+		if err := h.UploadCSV(c); err != nil {
+			assert.Error(t, err)
+		}
+		assert.Equal(t, tc.expectedStatus, rec.Code)
+		assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+	})
+
+	t.Run("CSV file with tax year", func(t *testing.T) {
+		tc := testcase{
+			fileContent:    "taxYear,totalIncome,wht,donation\n2566,500000,0,0\n2567,500000,0,0",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"taxes":[` +
+				`{"totalIncome":500000,"tax":29000,"taxableIncome":440000,"deductions":60000,"effectiveRate":0.058,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":471000},` +
+				`{"totalIncome":500000,"tax":29000,"taxableIncome":440000,"deductions":60000,"effectiveRate":0.058,"marginalRate":0.1,"bracket":"150,001-500,000","netIncome":471000}]}`,
 		}
 
 		body := new(bytes.Buffer)
@@ -314,7 +1764,8 @@ func TestHandler_UploadCSV(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
 
 		if err := h.UploadCSV(c); err != nil {
 			assert.Error(t, err)
@@ -479,7 +1930,8 @@ func TestHandler_UploadCSV(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
 
 		if err := h.UploadCSV(c); err != nil {
 			assert.Error(t, err)
@@ -515,7 +1967,8 @@ func TestHandler_UploadCSV(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
 
 		if err := h.UploadCSV(c); err != nil {
 			assert.Error(t, err)
@@ -551,7 +2004,8 @@ func TestHandler_UploadCSV(t *testing.T) {
 			settingRepo: settingRepo,
 		}
 
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
 
 		if err := h.UploadCSV(c); err != nil {
 			assert.Error(t, err)
@@ -563,10 +2017,9 @@ func TestHandler_UploadCSV(t *testing.T) {
 
 	t.Run("calculation error", func(t *testing.T) {
 		tc := testcase{
-			fileContent:     "totalIncome,wht,donation\n500000,0,0\n600000,40000,-20000\n750000,50000,15000",
-			expectedStatus:  http.StatusBadRequest,
-			mockCalculateFn: func(t *Tax) (float64, float64, []TaxLevel, error) { return 0, 0, nil, errors.New("calculation error") },
-			expectedBody:    `{"error":"calculation error"}`,
+			fileContent:    "totalIncome,wht,donation,taxYear\n500000,0,0,2567\n600000,40000,20000,2500\n750000,50000,15000,2567",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"unsupported tax year: 2500"}`,
 		}
 
 		body := new(bytes.Buffer)
@@ -582,11 +2035,8 @@ func TestHandler_UploadCSV(t *testing.T) {
 
 		settingRepo := new(mockSetting.Repository)
 
-		originalCalculate := Calculate
-		Calculate = tc.mockCalculateFn
-		defer func() { Calculate = originalCalculate }()
-
-		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: 60000, KReceipt: 50000}, nil).Once()
+		settingRepo.On("Get").Return(&models.DeductionConfig{ID: 1, Personal: decimal.NewFromInt(60000), KReceipt: decimal.NewFromInt(50000)}, nil).Once()
+		settingRepo.On("GetBrackets", mock.AnythingOfType("int")).Return([]models.TaxBracket{}, nil)
 
 		h := &handler{
 			logger:      logger,
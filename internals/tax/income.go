@@ -0,0 +1,139 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	salaryIncome       = "40(1)"
+	serviceIncome      = "40(2)"
+	royaltyIncome      = "40(3)"
+	investmentIncome   = "40(4)"
+	rentalIncome       = "40(5)"
+	professionalIncome = "40(6)"
+	contractIncome     = "40(7)"
+	businessIncome     = "40(8)"
+)
+
+const baseCurrency = "THB"
+
+// satangPrecision keeps the satang of amounts converted to THB.
+const satangPrecision = 2
+
+const (
+	standardExpense = "standard"
+	actualExpense   = "actual"
+)
+
+// Income is one categorized income. Spouse marks the income of the spouse in
+// a joint filing, whose expense group caps are applied separately.
+// ForeignSource marks income earned outside Thailand.
+type Income struct {
+	Category      string
+	Amount        decimal.Decimal
+	Expense       decimal.Decimal
+	Spouse        bool
+	ForeignSource bool
+}
+
+type IncomeBreakdown struct {
+	Category      string
+	Amount        decimal.Decimal
+	Expense       decimal.Decimal
+	ExpenseMethod string
+	NetIncome     decimal.Decimal
+}
+
+// ExpenseRule is the standard expense deduction of an income category. A nil
+// Cap means the deduction is not limited, and categories sharing a Group are
+// capped together by the rule set's ExpenseGroupCaps.
+type ExpenseRule struct {
+	Rate        decimal.Decimal
+	Cap         *decimal.Decimal
+	Group       string
+	AllowActual bool
+}
+
+type expenseGroup struct {
+	name   string
+	spouse bool
+}
+
+func sumIncomes(incomes []Income) decimal.Decimal {
+	amount := decimal.Zero
+	for _, income := range incomes {
+		amount = amount.Add(income.Amount)
+	}
+
+	return amount
+}
+
+func validateIncomes(incomes []Income, ruleSet RuleSet) error {
+	for _, income := range incomes {
+		if _, ok := ruleSet.ExpenseRules[income.Category]; !ok {
+			return errs.ErrIncorrectIncomeCategory
+		}
+
+		if ok := utils.Gte(income.Amount, decimal.Zero); !ok {
+			return errs.ErrValueMustBePositive
+		}
+
+		if ok := utils.Gte(income.Expense, decimal.Zero); !ok {
+			return errs.ErrValueMustBePositive
+		}
+	}
+
+	return nil
+}
+
+func deductExpenses(incomes []Income, ruleSet RuleSet) []IncomeBreakdown {
+	breakdown := make([]IncomeBreakdown, len(incomes))
+	remainingByGroup := map[expenseGroup]decimal.Decimal{}
+
+	for i, income := range incomes {
+		rule := ruleSet.ExpenseRules[income.Category]
+
+		expense := income.Amount.Mul(rule.Rate)
+		if rule.Cap != nil {
+			expense = decimal.Min(expense, *rule.Cap)
+		}
+
+		if groupCap, ok := ruleSet.ExpenseGroupCaps[rule.Group]; ok {
+			group := expenseGroup{name: rule.Group, spouse: income.Spouse}
+			remaining, ok := remainingByGroup[group]
+			if !ok {
+				remaining = groupCap
+			}
+
+			expense = decimal.Min(expense, remaining)
+			remainingByGroup[group] = remaining.Sub(expense)
+		}
+
+		method := standardExpense
+		if rule.AllowActual && income.Expense.GreaterThan(expense) {
+			expense = decimal.Min(income.Expense, income.Amount)
+			method = actualExpense
+		}
+
+		breakdown[i] = IncomeBreakdown{
+			Category:      income.Category,
+			Amount:        income.Amount,
+			Expense:       expense,
+			ExpenseMethod: method,
+			NetIncome:     income.Amount.Sub(expense),
+		}
+	}
+
+	return breakdown
+}
+
+func sumExpenses(breakdown []IncomeBreakdown) decimal.Decimal {
+	amount := decimal.Zero
+	for _, income := range breakdown {
+		amount = amount.Add(income.Expense)
+	}
+
+	return amount
+}
@@ -0,0 +1,108 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDeductExpenses(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+
+	type expected struct {
+		expense string
+		method  string
+		net     string
+	}
+
+	tests := []struct {
+		name     string
+		incomes  []Income
+		expected []expected
+	}{
+		{
+			name:     "salary capped",
+			incomes:  []Income{{Category: salaryIncome, Amount: decimal.NewFromInt(300000)}},
+			expected: []expected{{"100000", standardExpense, "200000"}},
+		},
+		{
+			name: "salary and service share a cap",
+			incomes: []Income{
+				{Category: salaryIncome, Amount: decimal.NewFromInt(120000)},
+				{Category: serviceIncome, Amount: decimal.NewFromInt(100000)},
+			},
+			expected: []expected{{"60000", standardExpense, "60000"}, {"40000", standardExpense, "60000"}},
+		},
+		{
+			name:     "royalty capped",
+			incomes:  []Income{{Category: royaltyIncome, Amount: decimal.NewFromInt(300000)}},
+			expected: []expected{{"100000", standardExpense, "200000"}},
+		},
+		{
+			name:     "investment has no expense",
+			incomes:  []Income{{Category: investmentIncome, Amount: decimal.NewFromInt(50000)}},
+			expected: []expected{{"0", standardExpense, "50000"}},
+		},
+		{
+			name:     "rental standard expense",
+			incomes:  []Income{{Category: rentalIncome, Amount: decimal.NewFromInt(100000)}},
+			expected: []expected{{"30000", standardExpense, "70000"}},
+		},
+		{
+			name:     "rental actual expense",
+			incomes:  []Income{{Category: rentalIncome, Amount: decimal.NewFromInt(100000), Expense: decimal.NewFromInt(40000)}},
+			expected: []expected{{"40000", actualExpense, "60000"}},
+		},
+		{
+			name:     "business standard expense higher than actual",
+			incomes:  []Income{{Category: businessIncome, Amount: decimal.NewFromInt(1000000), Expense: decimal.NewFromInt(500000)}},
+			expected: []expected{{"600000", standardExpense, "400000"}},
+		},
+		{
+			name:     "actual expense limited to income",
+			incomes:  []Income{{Category: contractIncome, Amount: decimal.NewFromInt(100000), Expense: decimal.NewFromInt(150000)}},
+			expected: []expected{{"100000", actualExpense, "0"}},
+		},
+		{
+			name:     "actual expense not allowed",
+			incomes:  []Income{{Category: salaryIncome, Amount: decimal.NewFromInt(100000), Expense: decimal.NewFromInt(80000)}},
+			expected: []expected{{"50000", standardExpense, "50000"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := deductExpenses(tt.incomes, ruleSet)
+
+			assert.Len(t, breakdown, len(tt.expected))
+			for i, income := range breakdown {
+				assert.Equal(t, tt.incomes[i].Category, income.Category)
+				assert.Equal(t, tt.expected[i].expense, income.Expense.String())
+				assert.Equal(t, tt.expected[i].method, income.ExpenseMethod)
+				assert.Equal(t, tt.expected[i].net, income.NetIncome.String())
+			}
+		})
+	}
+}
+
+func TestValidateIncomes(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+
+	tests := []struct {
+		name        string
+		incomes     []Income
+		expectedErr error
+	}{
+		{"valid incomes", []Income{{Category: salaryIncome, Amount: decimal.NewFromInt(1000)}}, nil},
+		{"incorrect category", []Income{{Category: "40(9)", Amount: decimal.NewFromInt(1000)}}, errs.ErrIncorrectIncomeCategory},
+		{"negative amount", []Income{{Category: salaryIncome, Amount: decimal.NewFromInt(-1)}}, errs.ErrValueMustBePositive},
+		{"negative expense", []Income{{Category: businessIncome, Expense: decimal.NewFromInt(-1)}}, errs.ErrValueMustBePositive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedErr, validateIncomes(tt.incomes, ruleSet))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+const buddhistEraOffset = 543
+
+type Installment struct {
+	Number  int
+	DueDate time.Time
+	Amount  decimal.Decimal
+}
+
+// getFilingDeadline returns the last day to file the return for a Buddhist Era
+// tax year, 31 March of the following year.
+func getFilingDeadline(taxYear int) time.Time {
+	return time.Date(taxYear-buddhistEraOffset+1, time.March, 31, 0, 0, 0, 0, time.UTC)
+}
+
+// planInstallments splits the payable tax into equal installments when it
+// reaches the rule set's minimum and the return is filed by the deadline. The
+// first is due on filing and each later one at the end of the next month after
+// the deadline. The last installment takes the rounding remainder.
+func planInstallments(taxAmount decimal.Decimal, filingDate time.Time, ruleSet RuleSet) []Installment {
+	if filingDate.IsZero() || ruleSet.Installments < 2 || taxAmount.LessThan(ruleSet.InstallmentMinimumTax) {
+		return nil
+	}
+
+	deadline := getFilingDeadline(ruleSet.TaxYear)
+	if afterDay(filingDate, deadline) {
+		return nil
+	}
+
+	amount := utils.Round(taxAmount.Div(decimal.NewFromInt(int64(ruleSet.Installments))), ruleSet.Precision)
+	remaining := taxAmount
+
+	installments := make([]Installment, ruleSet.Installments)
+	for i := range installments {
+		dueDate := filingDate
+		if i > 0 {
+			dueDate = time.Date(deadline.Year(), deadline.Month()+time.Month(i)+1, 0, 0, 0, 0, 0, time.UTC)
+		}
+		if i == len(installments)-1 {
+			amount = remaining
+		}
+
+		installments[i] = Installment{
+			Number:  i + 1,
+			DueDate: dueDate,
+			Amount:  amount,
+		}
+		remaining = remaining.Sub(amount)
+	}
+
+	return installments
+}
@@ -0,0 +1,83 @@
+package tax
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPlanInstallments(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		taxAmount  string
+		filingDate time.Time
+		expected   []string
+	}{
+		{
+			name:       "three installments",
+			taxAmount:  "10000",
+			filingDate: date(2025, time.February, 15),
+			expected:   []string{"1 2025-02-15 3333.3", "2 2025-04-30 3333.3", "3 2025-05-31 3333.4"},
+		},
+		{
+			name:       "minimum tax for installments",
+			taxAmount:  "3000",
+			filingDate: date(2025, time.March, 31),
+			expected:   []string{"1 2025-03-31 1000", "2 2025-04-30 1000", "3 2025-05-31 1000"},
+		},
+		{
+			name:       "filed at noon on deadline",
+			taxAmount:  "3000",
+			filingDate: time.Date(2025, time.March, 31, 12, 0, 0, 0, time.UTC),
+			expected:   []string{"1 2025-03-31 1000", "2 2025-04-30 1000", "3 2025-05-31 1000"},
+		},
+		{
+			name:       "tax below minimum",
+			taxAmount:  "2999.9",
+			filingDate: date(2025, time.February, 15),
+		},
+		{
+			name:       "filed after deadline",
+			taxAmount:  "10000",
+			filingDate: date(2025, time.April, 1),
+		},
+		{
+			name:      "no filing date",
+			taxAmount: "10000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installments := planInstallments(decimal.RequireFromString(tt.taxAmount), tt.filingDate, ruleSet)
+
+			var actual []string
+			for _, installment := range installments {
+				actual = append(actual, fmt.Sprintf("%d %s %s", installment.Number, installment.DueDate.Format(time.DateOnly), installment.Amount))
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestCalculateInstallments(t *testing.T) {
+	result, err := NewCalculator().Calculate(Tax{
+		TaxYear:    2567,
+		Income:     decimal.NewFromInt(500000),
+		FilingDate: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, result.Installments, 3) {
+		assert.Equal(t, "9666.7", result.Installments[0].Amount.String())
+		assert.Equal(t, "9666.7", result.Installments[1].Amount.String())
+		assert.Equal(t, "9666.6", result.Installments[2].Amount.String())
+	}
+}
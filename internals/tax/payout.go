@@ -0,0 +1,88 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+)
+
+// Payout is a lump sum paid on leaving employment that the filer elects to tax
+// separately from the other income. LastSalary is the last monthly salary,
+// which sets the severance exemption.
+type Payout struct {
+	TaxYear        int
+	Severance      decimal.Decimal
+	ProvidentFund  decimal.Decimal
+	YearsOfService int
+	LastSalary     decimal.Decimal
+	Wht            decimal.Decimal
+}
+
+type PayoutResult struct {
+	Income        decimal.Decimal
+	Exemption     decimal.Decimal
+	Expense       decimal.Decimal
+	TaxableIncome decimal.Decimal
+	Tax           decimal.Decimal
+	Refund        decimal.Decimal
+	TaxLevels     []TaxLevel
+}
+
+// calculatePayout taxes a payout with the years-of-service formula. Severance
+// up to the last salary of the exempt months, and never above the exemption
+// cap, is exempt. An expense per year of service is deducted from the rest and,
+// after the minimum years of service, only half of what is left is taxed. No
+// allowances apply.
+func calculatePayout(p *Payout, ruleSet RuleSet) (PayoutResult, error) {
+	if err := validatePayout(p); err != nil {
+		return PayoutResult{}, err
+	}
+
+	exemption := decimal.Min(p.LastSalary.Mul(decimal.NewFromInt(int64(ruleSet.SeveranceExemptMonths))), ruleSet.SeveranceExemptionCap)
+	exemption = decimal.Min(exemption, p.Severance)
+
+	income := p.Severance.Add(p.ProvidentFund).Sub(exemption)
+	expense := decimal.Min(ruleSet.PayoutExpensePerYear.Mul(decimal.NewFromInt(int64(p.YearsOfService))), income)
+
+	taxableIncome := income.Sub(expense)
+	if p.YearsOfService >= ruleSet.PayoutHalvingMinYears {
+		taxableIncome = utils.Round(taxableIncome.Mul(ruleSet.PayoutTaxableRate), ruleSet.Precision)
+	}
+
+	taxAmount, taxLevels := calculateProgressiveTax(taxableIncome, ruleSet, nil)
+	taxAmount = taxAmount.Sub(p.Wht)
+
+	refundAmount := decimal.Zero
+	if taxAmount.IsNegative() {
+		refundAmount = taxAmount.Abs()
+		taxAmount = decimal.Zero
+	}
+
+	return PayoutResult{
+		Income:        income,
+		Exemption:     exemption,
+		Expense:       expense,
+		TaxableIncome: taxableIncome,
+		Tax:           taxAmount,
+		Refund:        refundAmount,
+		TaxLevels:     taxLevels,
+	}, nil
+}
+
+func validatePayout(p *Payout) error {
+	if p.YearsOfService <= 0 {
+		return errs.ErrYearsOfServiceRequired
+	}
+
+	for _, amount := range []decimal.Decimal{p.Severance, p.ProvidentFund, p.LastSalary, p.Wht} {
+		if ok := utils.Gte(amount, decimal.Zero); !ok {
+			return errs.ErrValueMustBePositive
+		}
+	}
+
+	if ok := utils.Lte(p.Wht, p.Severance.Add(p.ProvidentFund)); !ok {
+		return errs.ErrWhtMustLowerThanOrEqualIncome
+	}
+
+	return nil
+}
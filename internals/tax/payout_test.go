@@ -0,0 +1,125 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalculatePayout(t *testing.T) {
+	tests := []struct {
+		name                  string
+		payout                Payout
+		expectedExemption     string
+		expectedExpense       string
+		expectedTaxableIncome string
+		expectedTax           string
+		expectedRefund        string
+	}{
+		{
+			name: "severance exempt up to ten months of salary",
+			payout: Payout{
+				Severance:      decimal.NewFromInt(1000000),
+				YearsOfService: 20,
+				LastSalary:     decimal.NewFromInt(50000),
+			},
+			expectedExemption:     "500000",
+			expectedExpense:       "140000",
+			expectedTaxableIncome: "180000",
+			expectedTax:           "3000",
+			expectedRefund:        "0",
+		},
+		{
+			name: "severance exemption capped with provident fund",
+			payout: Payout{
+				Severance:      decimal.NewFromInt(2000000),
+				ProvidentFund:  decimal.NewFromInt(500000),
+				YearsOfService: 30,
+				LastSalary:     decimal.NewFromInt(100000),
+			},
+			expectedExemption:     "600000",
+			expectedExpense:       "210000",
+			expectedTaxableIncome: "845000",
+			expectedTax:           "86750",
+			expectedRefund:        "0",
+		},
+		{
+			name: "expense limited to payout",
+			payout: Payout{
+				Severance:      decimal.NewFromInt(300000),
+				YearsOfService: 3,
+				LastSalary:     decimal.NewFromInt(50000),
+			},
+			expectedExemption:     "300000",
+			expectedExpense:       "0",
+			expectedTaxableIncome: "0",
+			expectedTax:           "0",
+			expectedRefund:        "0",
+		},
+		{
+			name: "not halved under five years with refund",
+			payout: Payout{
+				ProvidentFund:  decimal.NewFromInt(1000000),
+				YearsOfService: 4,
+				Wht:            decimal.NewFromInt(120000),
+			},
+			expectedExemption:     "0",
+			expectedExpense:       "28000",
+			expectedTaxableIncome: "972000",
+			expectedTax:           "0",
+			expectedRefund:        "14200",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCalculator().CalculatePayout(tt.payout)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedExemption, result.Exemption.String())
+			assert.Equal(t, tt.expectedExpense, result.Expense.String())
+			assert.Equal(t, tt.expectedTaxableIncome, result.TaxableIncome.String())
+			assert.Equal(t, tt.expectedTax, result.Tax.String())
+			assert.Equal(t, tt.expectedRefund, result.Refund.String())
+			assert.Len(t, result.TaxLevels, 5)
+		})
+	}
+}
+
+func TestCalculatePayoutError(t *testing.T) {
+	tests := []struct {
+		name        string
+		payout      Payout
+		expectedErr error
+	}{
+		{
+			name:        "no years of service",
+			payout:      Payout{Severance: decimal.NewFromInt(100000)},
+			expectedErr: errs.ErrYearsOfServiceRequired,
+		},
+		{
+			name:        "negative severance",
+			payout:      Payout{Severance: decimal.NewFromInt(-1), YearsOfService: 1},
+			expectedErr: errs.ErrValueMustBePositive,
+		},
+		{
+			name:        "wht greater than payout",
+			payout:      Payout{Severance: decimal.NewFromInt(100000), YearsOfService: 1, Wht: decimal.NewFromInt(100001)},
+			expectedErr: errs.ErrWhtMustLowerThanOrEqualIncome,
+		},
+		{
+			name:        "unsupported tax year",
+			payout:      Payout{TaxYear: 2570, YearsOfService: 1},
+			expectedErr: errs.ErrUnsupportedTaxYear,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCalculator().CalculatePayout(tt.payout)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
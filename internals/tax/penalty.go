@@ -0,0 +1,79 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+	"slices"
+	"time"
+)
+
+type Penalty struct {
+	Result    Result
+	Deadline  time.Time
+	Months    int
+	Surcharge decimal.Decimal
+	Fine      decimal.Decimal
+	Total     decimal.Decimal
+}
+
+// calculatePenalty adds the late payment surcharge and late filing fine to the
+// tax due as of the payment date. The surcharge is charged for every month or
+// part of a month after the filing deadline and never exceeds the tax due. The
+// fine applies when tax is due and the return, filed on the Tax's FilingDate
+// or else on the payment date, is late.
+func calculatePenalty(t *Tax, paymentDate time.Time) (Penalty, error) {
+	tax := *t
+	tax.Allowances = slices.Clone(t.Allowances)
+
+	result, err := calculateCopy(&tax)
+	if err != nil {
+		return Penalty{}, err
+	}
+
+	ruleSet, err := t.getRuleSet()
+	if err != nil {
+		return Penalty{}, err
+	}
+
+	deadline := getFilingDeadline(ruleSet.TaxYear)
+	months := getLateMonths(deadline, paymentDate)
+
+	surcharge := utils.Round(result.Tax.Mul(ruleSet.LateSurchargeRate).Mul(decimal.NewFromInt(int64(months))), ruleSet.Precision)
+	surcharge = decimal.Min(surcharge, result.Tax)
+
+	filingDate := t.FilingDate
+	if filingDate.IsZero() {
+		filingDate = paymentDate
+	}
+
+	fine := decimal.Zero
+	if result.Tax.IsPositive() && afterDay(filingDate, deadline) {
+		fine = ruleSet.LateFilingFine
+	}
+
+	return Penalty{
+		Result:    result,
+		Deadline:  deadline,
+		Months:    months,
+		Surcharge: surcharge,
+		Fine:      fine,
+		Total:     result.Tax.Add(surcharge).Add(fine),
+	}, nil
+}
+
+// getLateMonths counts the months or parts of a month from the deadline, the
+// last day of its month, to the date.
+func getLateMonths(deadline, date time.Time) int {
+	months := 0
+	for afterDay(date, time.Date(deadline.Year(), deadline.Month()+time.Month(months)+1, 0, 0, 0, 0, 0, time.UTC)) {
+		months++
+	}
+
+	return months
+}
+
+// afterDay reports whether the date falls on a later calendar day than day,
+// whatever its time of day.
+func afterDay(date, day time.Time) bool {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).After(day)
+}
@@ -0,0 +1,109 @@
+package tax
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCalculatePenalty(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name              string
+		wht               int64
+		filingDate        time.Time
+		paymentDate       time.Time
+		expectedMonths    int
+		expectedSurcharge string
+		expectedFine      string
+		expectedTotal     string
+	}{
+		{
+			name:              "paid on deadline",
+			paymentDate:       date(2025, time.March, 31),
+			expectedSurcharge: "0",
+			expectedFine:      "0",
+			expectedTotal:     "29000",
+		},
+		{
+			name:              "paid at noon on deadline",
+			paymentDate:       time.Date(2025, time.March, 31, 12, 0, 0, 0, time.UTC),
+			expectedSurcharge: "0",
+			expectedFine:      "0",
+			expectedTotal:     "29000",
+		},
+		{
+			name:              "filed at noon on deadline and paid late",
+			filingDate:        time.Date(2025, time.March, 31, 12, 0, 0, 0, time.UTC),
+			paymentDate:       time.Date(2025, time.April, 30, 12, 0, 0, 0, time.UTC),
+			expectedMonths:    1,
+			expectedSurcharge: "435",
+			expectedFine:      "0",
+			expectedTotal:     "29435",
+		},
+		{
+			name:              "one day late",
+			paymentDate:       date(2025, time.April, 1),
+			expectedMonths:    1,
+			expectedSurcharge: "435",
+			expectedFine:      "200",
+			expectedTotal:     "29635",
+		},
+		{
+			name:              "part of a month counts as a month",
+			paymentDate:       date(2025, time.May, 1),
+			expectedMonths:    2,
+			expectedSurcharge: "870",
+			expectedFine:      "200",
+			expectedTotal:     "30070",
+		},
+		{
+			name:              "filed on time and paid late",
+			filingDate:        date(2025, time.March, 15),
+			paymentDate:       date(2025, time.June, 15),
+			expectedMonths:    3,
+			expectedSurcharge: "1305",
+			expectedFine:      "0",
+			expectedTotal:     "30305",
+		},
+		{
+			name:              "surcharge capped at tax",
+			paymentDate:       date(2031, time.January, 1),
+			expectedMonths:    70,
+			expectedSurcharge: "29000",
+			expectedFine:      "200",
+			expectedTotal:     "58200",
+		},
+		{
+			name:              "no tax due",
+			wht:               30000,
+			paymentDate:       date(2025, time.June, 15),
+			expectedMonths:    3,
+			expectedSurcharge: "0",
+			expectedFine:      "0",
+			expectedTotal:     "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			penalty, err := NewCalculator().CalculatePenalty(Tax{
+				TaxYear:    2567,
+				Income:     decimal.NewFromInt(500000),
+				Wht:        decimal.NewFromInt(tt.wht),
+				FilingDate: tt.filingDate,
+			}, tt.paymentDate)
+
+			assert.NoError(t, err)
+			assert.Equal(t, date(2025, time.March, 31), penalty.Deadline)
+			assert.Equal(t, tt.expectedMonths, penalty.Months)
+			assert.Equal(t, tt.expectedSurcharge, penalty.Surcharge.String())
+			assert.Equal(t, tt.expectedFine, penalty.Fine.String())
+			assert.Equal(t, tt.expectedTotal, penalty.Total.String())
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+const (
+	resident    = "resident"
+	nonResident = "non-resident"
+)
+
+const (
+	fullAllowance     = ""
+	prorateAllowance  = "prorate"
+	disallowAllowance = "disallow"
+)
+
+// Residency is the filer's tax residency for the year. A resident is in
+// Thailand for at least the rule set's minimum days; PartYear marks a resident
+// who was not there all year. Rate is the share of the year spent in Thailand,
+// which prorates the allowances of non-residents. Declared is false when neither a status nor days
+// were given and a full-year resident is assumed.
+type Residency struct {
+	Status   string
+	Days     int
+	PartYear bool
+	Rate     decimal.Decimal
+	Declared bool
+}
+
+func determineResidency(status string, days int, ruleSet RuleSet) (Residency, error) {
+	daysInYear := getDaysInYear(ruleSet.TaxYear)
+	if days < 0 || days > daysInYear {
+		return Residency{}, errs.ErrIncorrectDaysInThailand
+	}
+
+	if status != "" && status != resident && status != nonResident {
+		return Residency{}, errs.ErrIncorrectResidency
+	}
+
+	if status == "" && days == 0 {
+		return Residency{Status: resident, Rate: decimal.NewFromInt(1)}, nil
+	}
+
+	residency := Residency{Status: status, Days: days, Rate: decimal.NewFromInt(1), Declared: true}
+	if days > 0 {
+		byDays := nonResident
+		if days >= ruleSet.ResidencyMinDays {
+			byDays = resident
+		}
+
+		if status != "" && status != byDays {
+			return Residency{}, errs.ErrResidencyMismatch
+		}
+
+		residency.Status = byDays
+		residency.PartYear = byDays == resident && days < daysInYear
+		residency.Rate = utils.Round(decimal.NewFromInt(int64(days)).Div(decimal.NewFromInt(int64(daysInYear))), ratePrecision)
+	} else if status == nonResident {
+		return Residency{}, errs.ErrDaysInThailandRequired
+	}
+
+	return residency, nil
+}
+
+// allowanceRule returns how the allowance type is claimed under the residency.
+// Residents claim every type in full, even when they were not in Thailand all
+// year.
+func (r Residency) allowanceRule(t AllowanceType) string {
+	if r.Status == nonResident {
+		return t.NonResident
+	}
+
+	return fullAllowance
+}
+
+func (r Residency) apply(accepted *AcceptedAllowance, t AllowanceType, places int32) {
+	switch r.allowanceRule(t) {
+	case prorateAllowance:
+		accepted.limit(utils.Round(accepted.Accepted.Mul(r.Rate), places), t.Name+"-residency-prorated")
+	case disallowAllowance:
+		accepted.limit(decimal.Zero, t.Name+"-non-resident")
+	}
+}
+
+// getTaxableIncomes drops the income earned outside Thailand, which is not
+// taxed for non-residents.
+func getTaxableIncomes(incomes []Income, residency Residency) []Income {
+	if residency.Status != nonResident {
+		return incomes
+	}
+
+	var taxable []Income
+	for _, income := range incomes {
+		if !income.ForeignSource {
+			taxable = append(taxable, income)
+		}
+	}
+
+	return taxable
+}
+
+func getDaysInYear(taxYear int) int {
+	return time.Date(taxYear-buddhistEraOffset, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
@@ -0,0 +1,190 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetermineResidency(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           string
+		days             int
+		expectedStatus   string
+		expectedPartYear bool
+		expectedRate     string
+		expectedDeclared bool
+	}{
+		{
+			name:           "nothing given assumes full-year resident",
+			expectedStatus: resident,
+			expectedRate:   "1",
+		},
+		{
+			name:             "resident without days",
+			status:           resident,
+			expectedStatus:   resident,
+			expectedRate:     "1",
+			expectedDeclared: true,
+		},
+		{
+			name:             "full year in Thailand",
+			days:             366,
+			expectedStatus:   resident,
+			expectedRate:     "1",
+			expectedDeclared: true,
+		},
+		{
+			name:             "part-year resident from minimum days",
+			days:             180,
+			expectedStatus:   resident,
+			expectedPartYear: true,
+			expectedRate:     "0.4918",
+			expectedDeclared: true,
+		},
+		{
+			name:             "non-resident under minimum days",
+			status:           nonResident,
+			days:             179,
+			expectedStatus:   nonResident,
+			expectedRate:     "0.4891",
+			expectedDeclared: true,
+		},
+	}
+
+	ruleSet, _ := GetRuleSet(2567)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			residency, err := determineResidency(tt.status, tt.days, ruleSet)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, residency.Status)
+			assert.Equal(t, tt.expectedPartYear, residency.PartYear)
+			assert.Equal(t, tt.expectedRate, residency.Rate.String())
+			assert.Equal(t, tt.expectedDeclared, residency.Declared)
+		})
+	}
+}
+
+func TestDetermineResidencyError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		days        int
+		expectedErr error
+	}{
+		{
+			name:        "unknown status",
+			status:      "tourist",
+			expectedErr: errs.ErrIncorrectResidency,
+		},
+		{
+			name:        "negative days",
+			days:        -1,
+			expectedErr: errs.ErrIncorrectDaysInThailand,
+		},
+		{
+			name:        "more days than the year",
+			days:        367,
+			expectedErr: errs.ErrIncorrectDaysInThailand,
+		},
+		{
+			name:        "non-resident without days",
+			status:      nonResident,
+			expectedErr: errs.ErrDaysInThailandRequired,
+		},
+		{
+			name:        "resident with too few days",
+			status:      resident,
+			days:        100,
+			expectedErr: errs.ErrResidencyMismatch,
+		},
+	}
+
+	ruleSet, _ := GetRuleSet(2567)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := determineResidency(tt.status, tt.days, ruleSet)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestCalculateResidency(t *testing.T) {
+	tests := []struct {
+		name                  string
+		days                  int
+		expectedPersonal      string
+		expectedSpouse        string
+		expectedDonation      string
+		expectedTaxableIncome string
+		expectedTax           string
+	}{
+		{
+			name:                  "full-year resident",
+			days:                  366,
+			expectedPersonal:      "60000",
+			expectedSpouse:        "60000",
+			expectedDonation:      "10000",
+			expectedTaxableIncome: "1070000",
+			expectedTax:           "124000",
+		},
+		{
+			name:                  "part-year resident claims full allowances",
+			days:                  183,
+			expectedPersonal:      "60000",
+			expectedSpouse:        "60000",
+			expectedDonation:      "10000",
+			expectedTaxableIncome: "1070000",
+			expectedTax:           "124000",
+		},
+		{
+			name:                  "non-resident is taxed on Thai-sourced income only",
+			days:                  90,
+			expectedPersonal:      "14754",
+			expectedSpouse:        "0",
+			expectedDonation:      "10000",
+			expectedTaxableIncome: "875246",
+			expectedTax:           "91286.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCalculator().Calculate(Tax{
+				TaxYear: 2567,
+				Incomes: []Income{
+					{Category: salaryIncome, Amount: decimal.NewFromInt(1000000)},
+					{Category: salaryIncome, Amount: decimal.NewFromInt(300000), ForeignSource: true},
+				},
+				Allowances: []Allowance{
+					{AllowanceType: spouse, Amount: decimal.NewFromInt(60000)},
+					{AllowanceType: donation, Amount: decimal.NewFromInt(10000)},
+				},
+				DaysInThailand: tt.days,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedPersonal, findAccepted(result.Allowances, personal))
+			assert.Equal(t, tt.expectedSpouse, findAccepted(result.Allowances, spouse))
+			assert.Equal(t, tt.expectedDonation, findAccepted(result.Allowances, donation))
+			assert.Equal(t, tt.expectedTaxableIncome, result.TaxableIncome.String())
+			assert.Equal(t, tt.expectedTax, result.Tax.String())
+		})
+	}
+}
+
+func findAccepted(allowances []AcceptedAllowance, allowanceType string) string {
+	for _, allowance := range allowances {
+		if allowance.AllowanceType == allowanceType {
+			return allowance.Accepted.String()
+		}
+	}
+
+	return ""
+}
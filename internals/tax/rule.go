@@ -0,0 +1,203 @@
+package tax
+
+import (
+	"fmt"
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+	"maps"
+	"slices"
+)
+
+const defaultTaxYear = 2567
+
+type Bracket struct {
+	Lower        decimal.Decimal
+	Upper        decimal.Decimal
+	Rate         decimal.Decimal
+	NoUpperLimit bool
+}
+
+type RuleSet struct {
+	TaxYear                  int
+	Brackets                 []Bracket
+	DefaultPersonalAllowance decimal.Decimal
+	DefaultKReceiptAllowance decimal.Decimal
+	SpouseAllowance          decimal.Decimal
+	ChildAllowance           decimal.Decimal
+	LaterChildAllowance      decimal.Decimal
+	LaterChildBirthYear      int
+	ParentAllowance          decimal.Decimal
+	ParentMinAge             int
+	ParentMaxIncome          decimal.Decimal
+	MaxParents               int
+	LifeInsuranceCap         decimal.Decimal
+	HealthInsuranceCap       decimal.Decimal
+	LifeHealthInsuranceCap   decimal.Decimal
+	ParentHealthInsuranceCap decimal.Decimal
+	AnnuityIncomeRate        decimal.Decimal
+	AnnuityCap               decimal.Decimal
+	ProvidentFundIncomeRate  decimal.Decimal
+	ProvidentFundCap         decimal.Decimal
+	RMFIncomeRate            decimal.Decimal
+	RMFCap                   decimal.Decimal
+	SSFIncomeRate            decimal.Decimal
+	SSFCap                   decimal.Decimal
+	ThaiESGIncomeRate        decimal.Decimal
+	ThaiESGCap               decimal.Decimal
+	RetirementCap            decimal.Decimal
+	DonationNetIncomeRate    decimal.Decimal
+	DoubleDonationMultiplier decimal.Decimal
+	PoliticalDonationCap     decimal.Decimal
+	ExpenseRules             map[string]ExpenseRule
+	ExpenseGroupCaps         map[string]decimal.Decimal
+	MinimumTaxRate           decimal.Decimal
+	MinimumTaxThreshold      decimal.Decimal
+	MinimumTaxExemption      decimal.Decimal
+	InstallmentMinimumTax    decimal.Decimal
+	Installments             int
+	LateSurchargeRate        decimal.Decimal
+	LateFilingFine           decimal.Decimal
+	DividendWithholdingRate  decimal.Decimal
+	SeveranceExemptMonths    int
+	SeveranceExemptionCap    decimal.Decimal
+	PayoutExpensePerYear     decimal.Decimal
+	PayoutHalvingMinYears    int
+	PayoutTaxableRate        decimal.Decimal
+	ResidencyMinDays         int
+	Precision                int32
+}
+
+var defaultBrackets = []Bracket{
+	{decimal.Zero, decimal.NewFromInt(150000), decimal.Zero, false},
+	{decimal.NewFromInt(150000), decimal.NewFromInt(500000), decimal.RequireFromString("0.10"), false},
+	{decimal.NewFromInt(500000), decimal.NewFromInt(1000000), decimal.RequireFromString("0.15"), false},
+	{decimal.NewFromInt(1000000), decimal.NewFromInt(2000000), decimal.RequireFromString("0.20"), false},
+	{decimal.NewFromInt(2000000), decimal.Zero, decimal.RequireFromString("0.35"), true},
+}
+
+const employmentExpenseGroup = "40(1)-40(2)"
+
+var defaultExpenseRules = map[string]ExpenseRule{
+	salaryIncome:       {Rate: decimal.RequireFromString("0.50"), Group: employmentExpenseGroup},
+	serviceIncome:      {Rate: decimal.RequireFromString("0.50"), Group: employmentExpenseGroup},
+	royaltyIncome:      {Rate: decimal.RequireFromString("0.50"), Cap: utils.ToPointer(decimal.NewFromInt(100000))},
+	investmentIncome:   {Rate: decimal.Zero},
+	rentalIncome:       {Rate: decimal.RequireFromString("0.30"), AllowActual: true},
+	professionalIncome: {Rate: decimal.RequireFromString("0.30"), AllowActual: true},
+	contractIncome:     {Rate: decimal.RequireFromString("0.60"), AllowActual: true},
+	businessIncome:     {Rate: decimal.RequireFromString("0.60"), AllowActual: true},
+}
+
+var baseRuleSet = RuleSet{
+	Brackets:                 defaultBrackets,
+	DefaultPersonalAllowance: decimal.NewFromInt(60000),
+	DefaultKReceiptAllowance: decimal.NewFromInt(50000),
+	SpouseAllowance:          decimal.NewFromInt(60000),
+	ChildAllowance:           decimal.NewFromInt(30000),
+	LaterChildAllowance:      decimal.NewFromInt(60000),
+	LaterChildBirthYear:      2561,
+	ParentAllowance:          decimal.NewFromInt(30000),
+	ParentMinAge:             60,
+	ParentMaxIncome:          decimal.NewFromInt(30000),
+	MaxParents:               4,
+	LifeInsuranceCap:         decimal.NewFromInt(100000),
+	HealthInsuranceCap:       decimal.NewFromInt(25000),
+	LifeHealthInsuranceCap:   decimal.NewFromInt(100000),
+	ParentHealthInsuranceCap: decimal.NewFromInt(15000),
+	AnnuityIncomeRate:        decimal.RequireFromString("0.15"),
+	AnnuityCap:               decimal.NewFromInt(200000),
+	ProvidentFundIncomeRate:  decimal.RequireFromString("0.15"),
+	ProvidentFundCap:         decimal.NewFromInt(500000),
+	RMFIncomeRate:            decimal.RequireFromString("0.30"),
+	RMFCap:                   decimal.NewFromInt(500000),
+	SSFIncomeRate:            decimal.RequireFromString("0.30"),
+	SSFCap:                   decimal.NewFromInt(200000),
+	ThaiESGIncomeRate:        decimal.RequireFromString("0.30"),
+	ThaiESGCap:               decimal.NewFromInt(300000),
+	RetirementCap:            decimal.NewFromInt(500000),
+	DonationNetIncomeRate:    decimal.RequireFromString("0.10"),
+	DoubleDonationMultiplier: decimal.NewFromInt(2),
+	PoliticalDonationCap:     decimal.NewFromInt(10000),
+	ExpenseRules:             defaultExpenseRules,
+	ExpenseGroupCaps: map[string]decimal.Decimal{
+		employmentExpenseGroup: decimal.NewFromInt(100000),
+	},
+	MinimumTaxRate:          decimal.RequireFromString("0.005"),
+	MinimumTaxThreshold:     decimal.NewFromInt(120000),
+	MinimumTaxExemption:     decimal.NewFromInt(5000),
+	InstallmentMinimumTax:   decimal.NewFromInt(3000),
+	Installments:            3,
+	LateSurchargeRate:       decimal.RequireFromString("0.015"),
+	LateFilingFine:          decimal.NewFromInt(200),
+	DividendWithholdingRate: decimal.RequireFromString("0.10"),
+	SeveranceExemptMonths:   10,
+	SeveranceExemptionCap:   decimal.NewFromInt(600000),
+	PayoutExpensePerYear:    decimal.NewFromInt(7000),
+	PayoutHalvingMinYears:   5,
+	PayoutTaxableRate:       decimal.RequireFromString("0.50"),
+	ResidencyMinDays:        180,
+	Precision:               precision,
+}
+
+var ruleSets = map[int]RuleSet{
+	2566: newRuleSet(2566, func(r *RuleSet) {
+		r.DefaultKReceiptAllowance = decimal.NewFromInt(40000)
+	}),
+	2567: newRuleSet(2567),
+	2568: newRuleSet(2568),
+}
+
+func newRuleSet(taxYear int, overrides ...func(r *RuleSet)) RuleSet {
+	ruleSet := baseRuleSet.clone()
+	ruleSet.TaxYear = taxYear
+	for _, override := range overrides {
+		override(&ruleSet)
+	}
+
+	return ruleSet
+}
+
+func GetRuleSet(taxYear int) (RuleSet, error) {
+	if taxYear == 0 {
+		taxYear = defaultTaxYear
+	}
+
+	ruleSet, ok := ruleSets[taxYear]
+	if !ok {
+		return RuleSet{}, fmt.Errorf("%w: %d", errs.ErrUnsupportedTaxYear, taxYear)
+	}
+
+	return ruleSet.clone(), nil
+}
+
+// clone copies the brackets and expense rules, so changing the copy leaves the
+// rule sets of the tax years untouched.
+func (r RuleSet) clone() RuleSet {
+	r.Brackets = slices.Clone(r.Brackets)
+	r.ExpenseGroupCaps = maps.Clone(r.ExpenseGroupCaps)
+
+	expenseRules := make(map[string]ExpenseRule, len(r.ExpenseRules))
+	for category, rule := range r.ExpenseRules {
+		if rule.Cap != nil {
+			rule.Cap = utils.ToPointer(*rule.Cap)
+		}
+		expenseRules[category] = rule
+	}
+	r.ExpenseRules = expenseRules
+
+	return r
+}
+
+func getLevelDescription(bracket Bracket) string {
+	lower := bracket.Lower
+	if lower.IsPositive() {
+		lower = lower.Add(decimal.NewFromInt(1))
+	}
+
+	if bracket.NoUpperLimit {
+		return fmt.Sprintf("%s ขึ้นไป", utils.FormatNumber(lower))
+	}
+
+	return fmt.Sprintf("%s-%s", utils.FormatNumber(lower), utils.FormatNumber(bracket.Upper))
+}
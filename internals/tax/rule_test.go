@@ -0,0 +1,79 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetRuleSet(t *testing.T) {
+	tests := []struct {
+		name            string
+		taxYear         int
+		expectedTaxYear int
+		expectedErr     error
+	}{
+		{"default tax year", 0, defaultTaxYear, nil},
+		{"tax year 2566", 2566, 2566, nil},
+		{"tax year 2567", 2567, 2567, nil},
+		{"tax year 2568", 2568, 2568, nil},
+		{"unsupported tax year", 2499, 0, errs.ErrUnsupportedTaxYear},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleSet, err := GetRuleSet(tt.taxYear)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedTaxYear, ruleSet.TaxYear)
+		})
+	}
+
+	t.Run("error message contains tax year", func(t *testing.T) {
+		_, err := GetRuleSet(2499)
+
+		assert.EqualError(t, err, "unsupported tax year: 2499")
+	})
+}
+
+func TestGetRuleSet_ReturnsCopy(t *testing.T) {
+	ruleSet, err := GetRuleSet(2567)
+	assert.NoError(t, err)
+
+	ruleSet.Brackets[1].Rate = decimal.RequireFromString("0.5")
+	ruleSet.ExpenseRules[salaryIncome] = ExpenseRule{Rate: decimal.Zero}
+	*ruleSet.ExpenseRules[royaltyIncome].Cap = decimal.Zero
+	ruleSet.ExpenseGroupCaps[employmentExpenseGroup] = decimal.Zero
+
+	for _, taxYear := range []int{2566, 2567} {
+		result, err := NewCalculator().Calculate(Tax{
+			TaxYear: taxYear,
+			Incomes: []Income{
+				{Category: salaryIncome, Amount: decimal.NewFromInt(400000)},
+				{Category: royaltyIncome, Amount: decimal.NewFromInt(400000)},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "41000", result.Tax.String(), "tax year %d", taxYear)
+	}
+}
+
+func TestGetLevelDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		bracket  Bracket
+		expected string
+	}{
+		{"first bracket", Bracket{decimal.Zero, decimal.NewFromInt(150000), decimal.Zero, false}, "0-150,000"},
+		{"middle bracket", Bracket{decimal.NewFromInt(150000), decimal.NewFromInt(500000), decimal.NewFromFloat(0.10), false}, "150,001-500,000"},
+		{"no upper limit", Bracket{decimal.NewFromInt(2000000), decimal.Zero, decimal.NewFromFloat(0.35), true}, "2,000,001 ขึ้นไป"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, getLevelDescription(tt.bracket))
+		})
+	}
+}
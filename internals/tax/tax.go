@@ -4,230 +4,419 @@ import (
 	"github.com/Atvit/assessment-tax/errs"
 	"github.com/Atvit/assessment-tax/utils"
 	"github.com/shopspring/decimal"
-	"math"
+	"slices"
+	"time"
 )
 
-const precision = 1
-
 const (
-	personal = "personal"
-	donation = "donation"
-	kReceipt = "k-receipt"
+	precision     = 1
+	ratePrecision = 4
 )
 
 const (
-	level1 = "level1"
-	level2 = "level2"
-	level3 = "level3"
-	level4 = "level4"
-	level5 = "level5"
+	progressiveMethod = "progressive"
+	minimumMethod     = "minimum"
 )
 
 const (
-	defaultPersonalAllowance = 60000.00
-	defaultKReceiptAllowance = 50000.00
-	maxDonationAllowance     = 100000.00
+	personal = "personal"
+	donation = "donation"
+	kReceipt = "k-receipt"
+	spouse   = "spouse"
+	child    = "child"
+	parent   = "parent"
+
+	lifeInsurance         = "life-insurance"
+	healthInsurance       = "health-insurance"
+	parentHealthInsurance = "parent-health-insurance"
+	annuityInsurance      = "annuity-insurance"
+
+	providentFund = "provident-fund"
+	rmf           = "rmf"
+	ssf           = "ssf"
+	thaiESG       = "thai-esg"
 )
 
-type TaxLevelMap map[string]TaxLevel
+const (
+	generalDonation   = "general"
+	educationDonation = "education"
+	hospitalDonation  = "hospital"
+	politicalDonation = "political"
+)
 
 type TaxLevel struct {
-	Level string   `json:"level"`
-	Tax   *float64 `json:"tax"`
+	Level string          `json:"level"`
+	Tax   decimal.Decimal `json:"tax"`
 }
 
 type AllowanceSetting struct {
-	Personal float64
-	KReceipt float64
+	Personal decimal.Decimal
+	KReceipt decimal.Decimal
 }
 
+// Allowance is one claim. Spouse marks the claim of the spouse in a joint
+// filing, which is capped apart from the filer's claims.
 type Allowance struct {
 	AllowanceType string
-	Amount        float64
+	Amount        decimal.Decimal
+	Category      string
+	BirthYear     int
+	Age           int
+	Income        decimal.Decimal
+	Spouse        bool
 }
 
+// Tax is the input of a calculation. SpouseIncome is the part of Income earned
+// by the spouse in a joint filing. The rule set and allowance setting are set
+// by the Calculator.
 type Tax struct {
-	Income           float64
-	Wht              float64
+	TaxYear          int
+	Income           decimal.Decimal
+	SpouseIncome     decimal.Decimal
+	Incomes          []Income
+	Wht              decimal.Decimal
 	Allowances       []Allowance
-	AllowanceSetting AllowanceSetting
+	Dividends        []Dividend
+	DividendElection string
+	Residency        string
+	DaysInThailand   int
+	Explain          bool
+	FilingDate       time.Time
+	ruleSet          *RuleSet
+	allowanceSetting AllowanceSetting
 }
 
-var Calculate = func(t *Tax) (float64, float64, []TaxLevel, error) {
-	err := validate(t)
-	if err != nil {
-		return 0, 0, nil, err
+type Result struct {
+	Tax           decimal.Decimal
+	Refund        decimal.Decimal
+	Method        string
+	TaxableIncome decimal.Decimal
+	Deductions    decimal.Decimal
+	EffectiveRate decimal.Decimal
+	MarginalRate  decimal.Decimal
+	Bracket       string
+	NetIncome     decimal.Decimal
+	TaxLevels     []TaxLevel
+	Allowances    []AcceptedAllowance
+	Incomes       []IncomeBreakdown
+	Steps         []Step
+	Installments  []Installment
+	Dividends     DividendTax
+	Residency     Residency
+}
+
+// calculateCopy calculates the tax of a copy of t, so the caller's Tax is never
+// changed.
+func calculateCopy(t *Tax) (Result, error) {
+	tax := t.clone()
+	if len(tax.Dividends) > 0 {
+		return electDividendTax(&tax)
 	}
 
-	addPersonalAllowance(t)
-	deductAmount := getDeductAmount(t.Allowances, t.AllowanceSetting)
-	taxableIncome := t.Income - deductAmount
+	return calculate(&tax, "")
+}
 
-	taxAmount, refundAmount, taxLevels := calculateTax(taxableIncome, t.Wht)
+func (t *Tax) clone() Tax {
+	tax := *t
+	tax.Incomes = slices.Clone(t.Incomes)
+	tax.Allowances = slices.Clone(t.Allowances)
+	tax.Dividends = slices.Clone(t.Dividends)
 
-	return taxAmount, refundAmount, taxLevels, nil
+	return tax
 }
 
-func addPersonalAllowance(t *Tax) {
-	personalAllowance := t.AllowanceSetting.Personal
-	if decimal.NewFromFloat(personalAllowance).IsZero() {
-		personalAllowance = defaultPersonalAllowance
+func (t *Tax) getRuleSet() (RuleSet, error) {
+	if t.ruleSet != nil {
+		return *t.ruleSet, nil
 	}
 
-	t.Allowances = append(t.Allowances, Allowance{
-		AllowanceType: "personal",
-		Amount:        personalAllowance,
-	})
+	return GetRuleSet(t.TaxYear)
 }
 
-func calculateTax(taxableIncome, wht float64) (float64, float64, []TaxLevel) {
-	taxAmount := 0.0
-	refundAmount := 0.0
+func calculate(t *Tax, election string) (Result, error) {
+	ruleSet, err := t.getRuleSet()
+	if err != nil {
+		return Result{}, err
+	}
 
-	taxAmount, taxLevels := calculateProgressiveTax(taxableIncome)
-	taxAmount -= wht
+	residency, err := determineResidency(t.Residency, t.DaysInThailand, ruleSet)
+	if err != nil {
+		return Result{}, err
+	}
 
-	if taxAmount < 0 {
-		refundAmount = math.Abs(taxAmount)
-		taxAmount = 0
+	if len(t.Incomes) > 0 {
+		t.Incomes = getTaxableIncomes(t.Incomes, residency)
+		t.Income = sumIncomes(t.Incomes)
 	}
 
-	return taxAmount, utils.Round(refundAmount, precision), taxLevels
-}
+	if err := validateDividends(t.Dividends); err != nil {
+		return Result{}, err
+	}
 
-func calculateProgressiveTax(taxableIncome float64) (float64, []TaxLevel) {
-	taxAmount := 0.0
-	taxLevelsMap := initializeTaxLevelsMap()
-	taxLevels := getTaxLevels(taxLevelsMap)
+	tr := newTrace(t.Explain)
+	tr.add(Step{Step: incomeStep, Description: "total income", Amount: t.Income})
 
-	taxBrackets := []struct {
-		lowerBound   float64
-		upperBound   float64
-		rate         float64
-		level        string
-		noUpperLimit bool
-	}{
-		{150000, 500000, 0.10, level2, false},
-		{500000, 1000000, 0.15, level3, false},
-		{1000000, 2000000, 0.20, level4, false},
-		{2000000, 0, 0.35, level5, true},
+	dividends := getDividendTax(t.Dividends, election, ruleSet, tr)
+	income := t.Income.Add(dividends.Income)
+	if dividends.Income.IsPositive() {
+		tr.add(Step{Step: incomeStep, Description: "dividends with tax credit", Base: dividends.Amount, Amount: dividends.Income})
 	}
 
-	for _, bracket := range taxBrackets {
-		if taxableIncome > bracket.lowerBound {
-			tax := 0.0
-			if bracket.noUpperLimit {
-				tax = (taxableIncome - bracket.lowerBound) * bracket.rate
-			} else {
-				tax = calculateTaxBracket(taxableIncome, bracket.lowerBound, bracket.upperBound, bracket.rate)
-			}
+	ctx := AllowanceContext{
+		RuleSet:      ruleSet,
+		Setting:      t.allowanceSetting,
+		Income:       income,
+		SpouseIncome: t.SpouseIncome,
+		Residency:    residency,
+	}
 
-			taxAmount += tax
-			updateTaxLevel(taxLevelsMap, bracket.level, utils.Round(tax, precision))
-		}
+	err = validate(t, ctx)
+	if err != nil {
+		return Result{}, err
 	}
 
-	return utils.Round(taxAmount, precision), taxLevels
+	incomes := deductExpenses(t.Incomes, ruleSet)
+	ctx.Expenses = sumExpenses(incomes)
+	for _, income := range incomes {
+		tr.add(Step{Step: expenseStep, Description: income.Category + " " + income.ExpenseMethod + " expense", Base: income.Amount, Amount: income.Expense})
+	}
+
+	addAutomaticAllowances(t, ctx, tr)
+	allowances := acceptAllowances(t.Allowances, ctx)
+	for _, allowance := range allowances {
+		tr.add(Step{Step: allowanceStep, Description: describeAllowance(allowance), Base: allowance.Amount, Amount: allowance.Accepted})
+	}
+
+	deductions := ctx.Expenses.Add(getDeductAmount(allowances))
+	taxableIncome := decimal.Max(income.Sub(deductions), decimal.Zero)
+	tr.add(Step{Step: taxableIncomeStep, Description: "income - expenses - allowances", Base: income, Amount: taxableIncome})
+
+	wht := t.Wht
+	if election == creditElection {
+		wht = wht.Add(dividends.Withheld)
+	}
+
+	taxAmount, refundAmount, taxLevels, method := calculateTax(taxableIncome, wht, dividends.Credit, incomes, ruleSet, tr)
+	totalTax := taxAmount.Add(t.Wht).Add(dividends.Withheld).Sub(refundAmount)
+	received := t.Income.Add(dividends.Amount)
+	marginalBracket := getMarginalBracket(taxableIncome, ruleSet.Brackets)
+
+	switch election {
+	case finalElection:
+		dividends.FinalTax = totalTax
+	case creditElection:
+		dividends.CreditTax = totalTax
+	}
+
+	return Result{
+		Tax:           taxAmount,
+		Refund:        refundAmount,
+		Method:        method,
+		TaxableIncome: taxableIncome,
+		Deductions:    deductions,
+		EffectiveRate: getEffectiveRate(totalTax, received),
+		MarginalRate:  marginalBracket.Rate,
+		Bracket:       getLevelDescription(marginalBracket),
+		NetIncome:     received.Sub(totalTax),
+		TaxLevels:     taxLevels,
+		Allowances:    allowances,
+		Incomes:       incomes,
+		Steps:         tr.result(),
+		Installments:  planInstallments(taxAmount, t.FilingDate, ruleSet),
+		Dividends:     dividends,
+		Residency:     residency,
+	}, nil
 }
 
-func calculateTaxBracket(income, lower, upper float64, rate float64) float64 {
-	if income <= upper {
-		return (income - lower) * rate
+func addAutomaticAllowances(t *Tax, ctx AllowanceContext, tr *trace) {
+	for _, allowanceType := range allowanceTypes.automatic() {
+		amount := allowanceType.Default(ctx)
+		tr.add(Step{Step: automaticAllowanceStep, Description: allowanceType.Name + " allowance added", Amount: amount})
+
+		t.Allowances = append(t.Allowances, Allowance{
+			AllowanceType: allowanceType.Name,
+			Amount:        amount,
+		})
 	}
+}
 
-	return (upper - lower) * rate
+func describeAllowance(allowance AcceptedAllowance) string {
+	description := allowance.AllowanceType
+	if allowance.Category != "" {
+		description += " (" + allowance.Category + ")"
+	}
+	if allowance.LimitedBy != "" {
+		description += " limited by " + allowance.LimitedBy
+	}
+
+	return description
 }
 
-func validate(t *Tax) error {
-	if ok := utils.Gte(t.Income, 0); !ok {
-		return errs.ErrValueMustBePositive
+func calculateTax(taxableIncome, wht, credit decimal.Decimal, incomes []IncomeBreakdown, ruleSet RuleSet, tr *trace) (decimal.Decimal, decimal.Decimal, []TaxLevel, string) {
+	refundAmount := decimal.Zero
+	method := progressiveMethod
+
+	taxAmount, taxLevels := calculateProgressiveTax(taxableIncome, ruleSet, tr)
+	if minimumTax := calculateMinimumTax(incomes, ruleSet, tr); minimumTax.GreaterThan(taxAmount) {
+		taxAmount = minimumTax
+		method = minimumMethod
 	}
+	tr.add(Step{Step: taxMethodStep, Description: method, Amount: taxAmount})
 
-	if ok := utils.Gte(t.Wht, 0); !ok {
-		return errs.ErrValueMustBePositive
+	tr.add(Step{Step: whtStep, Description: "withholding tax offset", Base: taxAmount, Amount: wht})
+	taxAmount = taxAmount.Sub(wht)
+
+	if credit.IsPositive() {
+		tr.add(Step{Step: dividendCreditStep, Description: "dividend tax credit offset", Base: taxAmount, Amount: credit})
+		taxAmount = taxAmount.Sub(credit)
 	}
 
-	if ok := utils.Lte(t.Wht, t.Income); !ok {
-		return errs.ErrWhtMustLowerThanOrEqualIncome
+	if taxAmount.IsNegative() {
+		refundAmount = taxAmount.Abs()
+		taxAmount = decimal.Zero
 	}
 
-	for _, allowance := range t.Allowances {
-		if ok := utils.Gte(allowance.Amount, 0); !ok {
-			return errs.ErrValueMustBePositive
-		}
+	refundAmount = tr.round(refundAmount, ruleSet.Precision, "refund")
+	tr.add(Step{Step: taxStep, Description: "tax payable", Amount: taxAmount})
+	tr.add(Step{Step: refundStep, Description: "tax refund", Amount: refundAmount})
 
-		if ok := utils.Oneof(allowance.AllowanceType, personal, donation, kReceipt); !ok {
-			return errs.ErrIncorrectAllowanceType
+	return taxAmount, refundAmount, taxLevels, method
+}
+
+func calculateMinimumTax(incomes []IncomeBreakdown, ruleSet RuleSet, tr *trace) decimal.Decimal {
+	income := decimal.Zero
+	for _, v := range incomes {
+		if v.Category != salaryIncome {
+			income = income.Add(v.Amount)
 		}
 	}
 
-	return nil
+	if income.LessThanOrEqual(ruleSet.MinimumTaxThreshold) {
+		return decimal.Zero
+	}
+
+	taxAmount := tr.round(income.Mul(ruleSet.MinimumTaxRate), ruleSet.Precision, minimumMethod+" tax")
+	if taxAmount.LessThanOrEqual(ruleSet.MinimumTaxExemption) {
+		return decimal.Zero
+	}
+
+	tr.add(Step{Step: minimumTaxStep, Description: "non-salary income", Base: income, Rate: ruleSet.MinimumTaxRate, Amount: taxAmount})
+	return taxAmount
 }
 
-func getDeductAmount(allowances []Allowance, setting AllowanceSetting) float64 {
-	amount := 0.00
+func calculateProgressiveTax(taxableIncome decimal.Decimal, ruleSet RuleSet, tr *trace) (decimal.Decimal, []TaxLevel) {
+	taxAmount := decimal.Zero
+	taxLevels := initializeTaxLevels(ruleSet.Brackets)
 
-	for _, allowance := range allowances {
-		if allowance.AllowanceType == donation {
-			if allowance.Amount > maxDonationAllowance {
-				allowance.Amount = maxDonationAllowance
+	for i, bracket := range ruleSet.Brackets {
+		if taxableIncome.GreaterThan(bracket.Lower) {
+			slice := taxableIncome.Sub(bracket.Lower)
+			if !bracket.NoUpperLimit {
+				slice = getBracketSlice(taxableIncome, bracket.Lower, bracket.Upper)
 			}
+
+			tax := slice.Mul(bracket.Rate)
+			tr.add(Step{Step: bracketStep, Description: taxLevels[i].Level, Base: slice, Rate: bracket.Rate, Amount: tax})
+
+			taxAmount = taxAmount.Add(tax)
+			taxLevels[i].Tax = taxLevels[i].Tax.Add(utils.Round(tax, ruleSet.Precision))
 		}
+	}
 
-		if allowance.AllowanceType == kReceipt {
-			if decimal.NewFromFloat(setting.KReceipt).IsZero() {
-				setting.KReceipt = defaultKReceiptAllowance
-			}
+	taxAmount = tr.round(taxAmount, ruleSet.Precision, progressiveMethod+" tax")
+	tr.add(Step{Step: progressiveTaxStep, Description: "sum of bracket tax", Amount: taxAmount})
 
-			if allowance.Amount > setting.KReceipt {
-				allowance.Amount = setting.KReceipt
-			}
+	return taxAmount, taxLevels
+}
+
+func getMarginalBracket(taxableIncome decimal.Decimal, brackets []Bracket) Bracket {
+	var marginal Bracket
+	for i, bracket := range brackets {
+		if i == 0 || taxableIncome.GreaterThan(bracket.Lower) {
+			marginal = bracket
 		}
+	}
 
-		amount += allowance.Amount
+	return marginal
+}
+
+func getEffectiveRate(totalTax, income decimal.Decimal) decimal.Decimal {
+	if !income.IsPositive() {
+		return decimal.Zero
 	}
 
-	return amount
+	return utils.Round(totalTax.Div(income), ratePrecision)
 }
 
-func initializeTaxLevelsMap() TaxLevelMap {
-	return map[string]TaxLevel{
-		level1: {Level: getLevelDescription(level1), Tax: new(float64)},
-		level2: {Level: getLevelDescription(level2), Tax: new(float64)},
-		level3: {Level: getLevelDescription(level3), Tax: new(float64)},
-		level4: {Level: getLevelDescription(level4), Tax: new(float64)},
-		level5: {Level: getLevelDescription(level5), Tax: new(float64)},
+func getTaxFreeThreshold(brackets []Bracket) decimal.Decimal {
+	threshold := decimal.Zero
+	for _, bracket := range brackets {
+		if !bracket.Rate.IsZero() || bracket.NoUpperLimit {
+			break
+		}
+		threshold = bracket.Upper
 	}
+
+	return threshold
 }
 
-func updateTaxLevel(taxLevels TaxLevelMap, level string, amount float64) {
-	if taxLevel, ok := taxLevels[level]; ok {
-		*taxLevel.Tax += amount
+func getBracketSlice(income, lower, upper decimal.Decimal) decimal.Decimal {
+	if income.LessThanOrEqual(upper) {
+		return income.Sub(lower)
 	}
+
+	return upper.Sub(lower)
 }
 
-func getLevelDescription(level string) string {
-	levelDescriptionMap := map[string]string{
-		level1: "0-150,000",
-		level2: "150,001-500,000",
-		level3: "500,001-1,000,000",
-		level4: "1,000,001-2,000,000",
-		level5: "2,000,001 ขึ้นไป",
+func validate(t *Tax, ctx AllowanceContext) error {
+	if err := validateIncomes(t.Incomes, ctx.RuleSet); err != nil {
+		return err
 	}
 
-	if description, ok := levelDescriptionMap[level]; ok {
-		return description
+	if ok := utils.Gte(t.Income, decimal.Zero); !ok {
+		return errs.ErrValueMustBePositive
+	}
+
+	if ok := utils.Gte(t.Wht, decimal.Zero); !ok {
+		return errs.ErrValueMustBePositive
+	}
+
+	if ok := utils.Lte(t.Wht, t.Income); !ok {
+		return errs.ErrWhtMustLowerThanOrEqualIncome
 	}
 
-	return ""
+	for _, allowance := range t.Allowances {
+		allowanceType, ok := allowanceTypes.get(allowance.AllowanceType)
+		if !ok {
+			return errs.ErrIncorrectAllowanceType
+		}
+
+		if err := allowanceType.validate(allowance, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getDeductAmount(allowances []AcceptedAllowance) decimal.Decimal {
+	amount := decimal.Zero
+
+	for _, allowance := range allowances {
+		amount = amount.Add(allowance.Accepted)
+	}
+
+	return amount
 }
 
-func getTaxLevels(taxLevels TaxLevelMap) []TaxLevel {
-	var levels []TaxLevel
-	keys := []string{level1, level2, level3, level4, level5}
-	for _, k := range keys {
-		levels = append(levels, taxLevels[k])
+func initializeTaxLevels(brackets []Bracket) []TaxLevel {
+	taxLevels := make([]TaxLevel, 0, len(brackets))
+	for _, bracket := range brackets {
+		taxLevels = append(taxLevels, TaxLevel{
+			Level: getLevelDescription(bracket),
+			Tax:   decimal.Zero,
+		})
 	}
 
-	return levels
+	return taxLevels
 }
@@ -2,22 +2,26 @@ package tax
 
 import (
 	"github.com/Atvit/assessment-tax/errs"
-	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
 func TestCalculateTax(t *testing.T) {
 	tests := []struct {
 		name             string
+		taxYear          int
 		income           float64
 		wht              float64
 		expectedTax      float64
 		expectedRefund   float64
 		expectedErr      error
 		expectedLevels   []TaxLevel
+		incomes          []Income
 		allowances       []Allowance
 		allowanceSetting AllowanceSetting
+		brackets         []Bracket
 	}{
 		{
 			name:           "negative income",
@@ -64,7 +68,7 @@ func TestCalculateTax(t *testing.T) {
 			expectedTax:    29000,
 			expectedRefund: 0,
 			expectedErr:    nil,
-			expectedLevels: getMockTaxLevels(TaxLevel{Level: level2, Tax: utils.ToPointer(29000.0)}),
+			expectedLevels: getMockTaxLevels(TaxLevel{Level: level2, Tax: decimal.NewFromFloat(29000.0)}),
 		},
 		{
 			name:           "income above upper bracket limit",
@@ -72,7 +76,7 @@ func TestCalculateTax(t *testing.T) {
 			expectedTax:    29000.1,
 			expectedRefund: 0,
 			expectedErr:    nil,
-			expectedLevels: getMockTaxLevels(TaxLevel{Level: level2, Tax: utils.ToPointer(29000.1)}),
+			expectedLevels: getMockTaxLevels(TaxLevel{Level: level2, Tax: decimal.NewFromFloat(29000.1)}),
 		},
 		{
 			name:           "income at second upper bracket limit",
@@ -81,8 +85,8 @@ func TestCalculateTax(t *testing.T) {
 			expectedRefund: 0,
 			expectedErr:    nil,
 			expectedLevels: getMockTaxLevels(
-				TaxLevel{level2, utils.ToPointer(35000.0)},
-				TaxLevel{level3, utils.ToPointer(66000.0)},
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(66000.0)},
 			),
 		},
 		{
@@ -92,8 +96,8 @@ func TestCalculateTax(t *testing.T) {
 			expectedRefund: 0,
 			expectedErr:    nil,
 			expectedLevels: getMockTaxLevels(
-				TaxLevel{level2, utils.ToPointer(35000.0)},
-				TaxLevel{level3, utils.ToPointer(66000.2)},
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(66000.2)},
 			),
 		},
 		{
@@ -103,9 +107,9 @@ func TestCalculateTax(t *testing.T) {
 			expectedRefund: 0,
 			expectedErr:    nil,
 			expectedLevels: getMockTaxLevels(
-				TaxLevel{level2, utils.ToPointer(35000.0)},
-				TaxLevel{level3, utils.ToPointer(75000.0)},
-				TaxLevel{level4, utils.ToPointer(188000.0)},
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(75000.0)},
+				TaxLevel{level4, decimal.NewFromFloat(188000.0)},
 			),
 		},
 		{
@@ -115,9 +119,9 @@ func TestCalculateTax(t *testing.T) {
 			expectedRefund: 0,
 			expectedErr:    nil,
 			expectedLevels: getMockTaxLevels(
-				TaxLevel{level2, utils.ToPointer(35000.0)},
-				TaxLevel{level3, utils.ToPointer(75000.0)},
-				TaxLevel{level4, utils.ToPointer(188000.2)},
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(75000.0)},
+				TaxLevel{level4, decimal.NewFromFloat(188000.2)},
 			),
 		},
 		{
@@ -127,10 +131,23 @@ func TestCalculateTax(t *testing.T) {
 			expectedRefund: 0,
 			expectedErr:    nil,
 			expectedLevels: getMockTaxLevels(
-				TaxLevel{level2, utils.ToPointer(35000.0)},
-				TaxLevel{level3, utils.ToPointer(75000.0)},
-				TaxLevel{level4, utils.ToPointer(200000.0)},
-				TaxLevel{level5, utils.ToPointer(329000.0)},
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(75000.0)},
+				TaxLevel{level4, decimal.NewFromFloat(200000.0)},
+				TaxLevel{level5, decimal.NewFromFloat(329000.0)},
+			),
+		},
+		{
+			name:           "large income without satang drift",
+			income:         123456789.99,
+			expectedTax:    42798876.5,
+			expectedRefund: 0,
+			expectedErr:    nil,
+			expectedLevels: getMockTaxLevels(
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(75000.0)},
+				TaxLevel{level4, decimal.NewFromFloat(200000.0)},
+				TaxLevel{level5, decimal.NewFromFloat(42488876.5)},
 			),
 		},
 		{
@@ -140,7 +157,7 @@ func TestCalculateTax(t *testing.T) {
 			expectedTax:    4000,
 			expectedRefund: 0,
 			expectedErr:    nil,
-			expectedLevels: getMockTaxLevels(TaxLevel{level2, utils.ToPointer(29000.0)}),
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(29000.0)}),
 		},
 		{
 			name:           "negative with holding tax",
@@ -164,14 +181,14 @@ func TestCalculateTax(t *testing.T) {
 			wht:            0,
 			expectedTax:    0,
 			expectedRefund: 0,
-			allowances:     []Allowance{{personal, 40000}, {"invalid", 50000}},
+			allowances:     []Allowance{{AllowanceType: personal, Amount: decimal.NewFromInt(40000)}, {AllowanceType: "invalid", Amount: decimal.NewFromInt(50000)}},
 			expectedErr:    errs.ErrIncorrectAllowanceType,
 		},
 		{
 			name:           "allowance amount less than zero",
 			income:         500000,
 			wht:            0,
-			allowances:     []Allowance{{personal, -1000}},
+			allowances:     []Allowance{{AllowanceType: personal, Amount: decimal.NewFromInt(-1000)}},
 			expectedTax:    0,
 			expectedRefund: 0,
 			expectedErr:    errs.ErrValueMustBePositive,
@@ -184,17 +201,17 @@ func TestCalculateTax(t *testing.T) {
 			expectedTax:    29000,
 			expectedRefund: 0,
 			expectedErr:    nil,
-			expectedLevels: getMockTaxLevels(TaxLevel{level2, utils.ToPointer(29000.0)}),
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(29000.0)}),
 		},
 		{
 			name:           "donation allowance",
 			income:         500000,
 			wht:            0,
-			allowances:     []Allowance{{donation, 200000}},
-			expectedTax:    19000,
+			allowances:     []Allowance{{AllowanceType: donation, Amount: decimal.NewFromInt(200000)}},
+			expectedTax:    24600,
 			expectedRefund: 0,
 			expectedErr:    nil,
-			expectedLevels: getMockTaxLevels(TaxLevel{level2, utils.ToPointer(19000.0)}),
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(24600.0)}),
 		},
 		{
 			name:           "get a refund if tax-exempt and have withholding tax",
@@ -208,51 +225,469 @@ func TestCalculateTax(t *testing.T) {
 			name:           "get a refund if withholding tax is greater than tax to pay",
 			income:         500000,
 			wht:            30000,
-			allowances:     []Allowance{{donation, 200000}},
+			allowances:     []Allowance{{AllowanceType: donation, Amount: decimal.NewFromInt(200000)}},
 			expectedTax:    0,
-			expectedRefund: 11000,
-			expectedLevels: getMockTaxLevels(TaxLevel{level2, utils.ToPointer(19000.0)}),
+			expectedRefund: 5400,
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(24600.0)}),
 		},
 		{
 			name:       "k-receipt allowance",
 			income:     500000,
 			wht:        0,
-			allowances: []Allowance{{kReceipt, 200000}, {donation, 100000}},
+			allowances: []Allowance{{AllowanceType: kReceipt, Amount: decimal.NewFromInt(200000)}, {AllowanceType: donation, Amount: decimal.NewFromInt(100000)}},
 			allowanceSetting: AllowanceSetting{
-				Personal: 60000.0,
-				KReceipt: 50000.0,
+				Personal: decimal.NewFromFloat(60000.0),
+				KReceipt: decimal.NewFromFloat(50000.0),
 			},
-			expectedTax:    14000,
+			expectedTax:    20100,
 			expectedRefund: 0,
-			expectedLevels: getMockTaxLevels(TaxLevel{level2, utils.ToPointer(14000.0)}),
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(20100.0)}),
 		},
 		{
 			name:           "default k-receipt allowance",
 			income:         500000,
 			wht:            0,
-			allowances:     []Allowance{{kReceipt, 200000}, {donation, 100000}},
-			expectedTax:    14000,
+			allowances:     []Allowance{{AllowanceType: kReceipt, Amount: decimal.NewFromInt(200000)}, {AllowanceType: donation, Amount: decimal.NewFromInt(100000)}},
+			expectedTax:    20100,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(20100.0)}),
+		},
+		{
+			name:           "default k-receipt allowance of tax year 2566",
+			taxYear:        2566,
+			income:         500000,
+			wht:            0,
+			allowances:     []Allowance{{AllowanceType: kReceipt, Amount: decimal.NewFromInt(200000)}, {AllowanceType: donation, Amount: decimal.NewFromInt(100000)}},
+			expectedTax:    21000,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(21000.0)}),
+		},
+		{
+			name:           "tax year 2568",
+			taxYear:        2568,
+			income:         500000,
+			wht:            0,
+			expectedTax:    29000,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(29000.0)}),
+		},
+		{
+			name:   "custom tax brackets",
+			income: 500000,
+			wht:    0,
+			brackets: []Bracket{
+				{decimal.Zero, decimal.NewFromInt(200000), decimal.Zero, false},
+				{decimal.NewFromInt(200000), decimal.Zero, decimal.NewFromFloat(0.2), true},
+			},
+			expectedTax:    48000,
 			expectedRefund: 0,
-			expectedLevels: getMockTaxLevels(TaxLevel{level2, utils.ToPointer(14000.0)}),
+			expectedLevels: []TaxLevel{{"0-200,000", decimal.NewFromFloat(0.0)}, {"200,001 ขึ้นไป", decimal.NewFromFloat(48000.0)}},
+		},
+		{
+			name:   "family allowances",
+			income: 800000,
+			wht:    0,
+			allowances: []Allowance{
+				{AllowanceType: spouse},
+				{AllowanceType: child, BirthYear: 2560},
+				{AllowanceType: child, BirthYear: 2563},
+				{AllowanceType: parent, Age: 65},
+			},
+			expectedTax:    44000,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(9000.0)},
+			),
+		},
+		{
+			name:   "insurance allowances",
+			income: 1000000,
+			wht:    0,
+			allowances: []Allowance{
+				{AllowanceType: lifeInsurance, Amount: decimal.NewFromInt(80000)},
+				{AllowanceType: healthInsurance, Amount: decimal.NewFromInt(30000)},
+				{AllowanceType: annuityInsurance, Amount: decimal.NewFromInt(200000)},
+			},
+			expectedTax:    63500,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(28500.0)},
+			),
+		},
+		{
+			name:   "retirement savings allowances",
+			income: 2000000,
+			wht:    0,
+			allowances: []Allowance{
+				{AllowanceType: providentFund, Amount: decimal.NewFromInt(300000)},
+				{AllowanceType: rmf, Amount: decimal.NewFromInt(300000)},
+				{AllowanceType: thaiESG, Amount: decimal.NewFromInt(100000)},
+			},
+			expectedTax:    178000,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(75000.0)},
+				TaxLevel{level4, decimal.NewFromFloat(68000.0)},
+			),
+		},
+		{
+			name:   "donation categories",
+			income: 1000000,
+			wht:    0,
+			allowances: []Allowance{
+				{AllowanceType: donation, Amount: decimal.NewFromInt(20000), Category: educationDonation},
+				{AllowanceType: donation, Amount: decimal.NewFromInt(30000)},
+				{AllowanceType: donation, Amount: decimal.NewFromInt(15000), Category: politicalDonation},
+			},
+			expectedTax:    89000,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(54000.0)},
+			),
+		},
+		{
+			name:           "incorrect donation category",
+			income:         500000,
+			allowances:     []Allowance{{AllowanceType: donation, Amount: decimal.NewFromInt(1000), Category: "temple"}},
+			expectedTax:    0,
+			expectedRefund: 0,
+			expectedErr:    errs.ErrIncorrectDonationCategory,
+		},
+		{
+			name: "categorized incomes",
+			wht:  0,
+			incomes: []Income{
+				{Category: salaryIncome, Amount: decimal.NewFromInt(600000)},
+				{Category: businessIncome, Amount: decimal.NewFromInt(500000)},
+			},
+			expectedTax:    56000,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(
+				TaxLevel{level2, decimal.NewFromFloat(35000.0)},
+				TaxLevel{level3, decimal.NewFromFloat(21000.0)},
+			),
+		},
+		{
+			name: "donation capped after expenses",
+			wht:  0,
+			incomes: []Income{
+				{Category: salaryIncome, Amount: decimal.NewFromInt(500000)},
+			},
+			allowances:     []Allowance{{AllowanceType: donation, Amount: decimal.NewFromInt(100000)}},
+			expectedTax:    15600,
+			expectedRefund: 0,
+			expectedLevels: getMockTaxLevels(TaxLevel{level2, decimal.NewFromFloat(15600.0)}),
+		},
+		{
+			name:           "incorrect income category",
+			incomes:        []Income{{Category: "40(9)", Amount: decimal.NewFromInt(100000)}},
+			expectedTax:    0,
+			expectedRefund: 0,
+			expectedErr:    errs.ErrIncorrectIncomeCategory,
+		},
+		{
+			name:           "spouse with income",
+			income:         800000,
+			allowances:     []Allowance{{AllowanceType: spouse, Income: decimal.NewFromInt(100000)}},
+			expectedTax:    0,
+			expectedRefund: 0,
+			expectedErr:    errs.ErrSpouseHasIncome,
+		},
+		{
+			name:           "unsupported tax year",
+			taxYear:        2500,
+			income:         500000,
+			wht:            0,
+			expectedTax:    0,
+			expectedRefund: 0,
+			expectedErr:    errs.ErrUnsupportedTaxYear,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			taxAmount, refundAmount, taxLevels, err := Calculate(&Tax{
-				Income:           tt.income,
-				Wht:              tt.wht,
-				Allowances:       tt.allowances,
-				AllowanceSetting: tt.allowanceSetting,
+			calculator := NewCalculator(WithAllowanceSetting(tt.allowanceSetting), WithBrackets(tt.brackets))
+			result, err := calculator.Calculate(Tax{
+				TaxYear:    tt.taxYear,
+				Income:     decimal.NewFromFloat(tt.income),
+				Incomes:    tt.incomes,
+				Wht:        decimal.NewFromFloat(tt.wht),
+				Allowances: tt.allowances,
 			})
 
-			assert.Equal(t, tt.expectedErr, err)
-			assert.Equal(t, tt.expectedTax, taxAmount)
-			assert.Equal(t, tt.expectedRefund, refundAmount)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, decimal.NewFromFloat(tt.expectedTax).String(), result.Tax.String())
+			assert.Equal(t, decimal.NewFromFloat(tt.expectedRefund).String(), result.Refund.String())
 
-			for i, level := range taxLevels {
-				assert.Equal(t, *tt.expectedLevels[i].Tax, *level.Tax)
+			for i, level := range result.TaxLevels {
+				assert.Equal(t, tt.expectedLevels[i].Tax.String(), level.Tax.String())
 			}
 		})
 	}
 }
+
+func TestCalculateMinimumTax(t *testing.T) {
+	ruleSet, _ := GetRuleSet(2567)
+
+	tests := []struct {
+		name     string
+		incomes  []Income
+		expected string
+	}{
+		{"salary only", []Income{{Category: salaryIncome, Amount: decimal.NewFromInt(5000000)}}, "0"},
+		{"at threshold", []Income{{Category: businessIncome, Amount: decimal.NewFromInt(120000)}}, "0"},
+		{"exempt amount", []Income{{Category: businessIncome, Amount: decimal.NewFromInt(1000000)}}, "0"},
+		{"above exempt amount", []Income{{Category: businessIncome, Amount: decimal.NewFromInt(2000000)}}, "10000"},
+		{
+			"salary excluded",
+			[]Income{
+				{Category: salaryIncome, Amount: decimal.NewFromInt(1000000)},
+				{Category: serviceIncome, Amount: decimal.NewFromInt(1500000)},
+			},
+			"7500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incomes := deductExpenses(tt.incomes, ruleSet)
+
+			assert.Equal(t, tt.expected, calculateMinimumTax(incomes, ruleSet, nil).String())
+		})
+	}
+}
+
+func TestCalculateTaxMethod(t *testing.T) {
+	tests := []struct {
+		name           string
+		incomes        []Income
+		wht            int64
+		expectedTax    string
+		expectedRefund string
+		expectedMethod string
+	}{
+		{
+			name:           "progressive tax is higher",
+			incomes:        []Income{{Category: businessIncome, Amount: decimal.NewFromInt(2000000)}},
+			expectedTax:    "71000",
+			expectedRefund: "0",
+			expectedMethod: progressiveMethod,
+		},
+		{
+			name:           "minimum tax is higher",
+			incomes:        []Income{{Category: businessIncome, Amount: decimal.NewFromInt(2000000), Expense: decimal.NewFromInt(1990000)}},
+			expectedTax:    "10000",
+			expectedRefund: "0",
+			expectedMethod: minimumMethod,
+		},
+		{
+			name:           "minimum tax with withholding tax",
+			incomes:        []Income{{Category: businessIncome, Amount: decimal.NewFromInt(2000000), Expense: decimal.NewFromInt(1990000)}},
+			wht:            15000,
+			expectedTax:    "0",
+			expectedRefund: "5000",
+			expectedMethod: minimumMethod,
+		},
+		{
+			name:           "uncategorized income",
+			expectedTax:    "0",
+			expectedRefund: "0",
+			expectedMethod: progressiveMethod,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCalculator().Calculate(Tax{
+				Incomes: tt.incomes,
+				Wht:     decimal.NewFromInt(tt.wht),
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedTax, result.Tax.String())
+			assert.Equal(t, tt.expectedRefund, result.Refund.String())
+			assert.Equal(t, tt.expectedMethod, result.Method)
+		})
+	}
+}
+
+func TestCalculateExplain(t *testing.T) {
+	tests := []struct {
+		name       string
+		income     string
+		wht        string
+		allowances []Allowance
+		explain    bool
+		expected   []string
+	}{
+		{
+			name:       "allowance capped and refund",
+			income:     "500000",
+			wht:        "25000",
+			allowances: []Allowance{{AllowanceType: kReceipt, Amount: decimal.NewFromInt(100000)}},
+			explain:    true,
+			expected: []string{
+				"income|total income|0|0|500000",
+				"automatic-allowance|personal allowance added|0|0|60000",
+				"allowance|k-receipt limited by k-receipt-max|100000|0|50000",
+				"allowance|personal|60000|0|60000",
+				"taxable-income|income - expenses - allowances|500000|0|390000",
+				"bracket|0-150,000|150000|0|0",
+				"bracket|150,001-500,000|240000|0.1|24000",
+				"progressive-tax|sum of bracket tax|0|0|24000",
+				"tax-method|progressive|0|0|24000",
+				"wht|withholding tax offset|24000|0|25000",
+				"tax|tax payable|0|0|0",
+				"refund|tax refund|0|0|1000",
+			},
+		},
+		{
+			name:    "rounding",
+			income:  "210333.33",
+			wht:     "0",
+			explain: true,
+			expected: []string{
+				"income|total income|0|0|210333.33",
+				"automatic-allowance|personal allowance added|0|0|60000",
+				"allowance|personal|60000|0|60000",
+				"taxable-income|income - expenses - allowances|210333.33|0|150333.33",
+				"bracket|0-150,000|150000|0|0",
+				"bracket|150,001-500,000|333.33|0.1|33.333",
+				"rounding|progressive tax|33.333|0|33.3",
+				"progressive-tax|sum of bracket tax|0|0|33.3",
+				"tax-method|progressive|0|0|33.3",
+				"wht|withholding tax offset|33.3|0|0",
+				"tax|tax payable|0|0|33.3",
+				"refund|tax refund|0|0|0",
+			},
+		},
+		{
+			name:   "explain disabled",
+			income: "500000",
+			wht:    "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCalculator().Calculate(Tax{
+				Income:     decimal.RequireFromString(tt.income),
+				Wht:        decimal.RequireFromString(tt.wht),
+				Allowances: tt.allowances,
+				Explain:    tt.explain,
+			})
+
+			assert.NoError(t, err)
+
+			var steps []string
+			for _, step := range result.Steps {
+				steps = append(steps, strings.Join([]string{step.Step, step.Description, step.Base.String(), step.Rate.String(), step.Amount.String()}, "|"))
+			}
+			assert.Equal(t, tt.expected, steps)
+		})
+	}
+}
+
+func TestCalculateSummary(t *testing.T) {
+	tests := []struct {
+		name                  string
+		income                int64
+		wht                   int64
+		incomes               []Income
+		expectedTaxable       string
+		expectedDeductions    string
+		expectedEffectiveRate string
+		expectedMarginalRate  string
+		expectedBracket       string
+		expectedNetIncome     string
+	}{
+		{
+			name:                  "tax free income",
+			income:                100000,
+			expectedTaxable:       "40000",
+			expectedDeductions:    "60000",
+			expectedEffectiveRate: "0",
+			expectedMarginalRate:  "0",
+			expectedBracket:       "0-150,000",
+			expectedNetIncome:     "100000",
+		},
+		{
+			name:                  "income below allowances",
+			income:                50000,
+			expectedTaxable:       "0",
+			expectedDeductions:    "60000",
+			expectedEffectiveRate: "0",
+			expectedMarginalRate:  "0",
+			expectedBracket:       "0-150,000",
+			expectedNetIncome:     "50000",
+		},
+		{
+			name:                  "withholding tax refunded",
+			income:                500000,
+			wht:                   30000,
+			expectedTaxable:       "440000",
+			expectedDeductions:    "60000",
+			expectedEffectiveRate: "0.058",
+			expectedMarginalRate:  "0.1",
+			expectedBracket:       "150,001-500,000",
+			expectedNetIncome:     "471000",
+		},
+		{
+			name:                  "expenses are deductions",
+			incomes:               []Income{{Category: salaryIncome, Amount: decimal.NewFromInt(1000000)}},
+			expectedTaxable:       "840000",
+			expectedDeductions:    "160000",
+			expectedEffectiveRate: "0.086",
+			expectedMarginalRate:  "0.15",
+			expectedBracket:       "500,001-1,000,000",
+			expectedNetIncome:     "914000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCalculator().Calculate(Tax{
+				Income:  decimal.NewFromInt(tt.income),
+				Incomes: tt.incomes,
+				Wht:     decimal.NewFromInt(tt.wht),
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedTaxable, result.TaxableIncome.String())
+			assert.Equal(t, tt.expectedDeductions, result.Deductions.String())
+			assert.Equal(t, tt.expectedEffectiveRate, result.EffectiveRate.String())
+			assert.Equal(t, tt.expectedMarginalRate, result.MarginalRate.String())
+			assert.Equal(t, tt.expectedBracket, result.Bracket)
+			assert.Equal(t, tt.expectedNetIncome, result.NetIncome.String())
+		})
+	}
+}
+
+func TestGetMarginalBracket(t *testing.T) {
+	tests := []struct {
+		name            string
+		taxable         int64
+		expectedRate    string
+		expectedBracket string
+	}{
+		{"negative taxable income", -1000, "0", "0-150,000"},
+		{"tax free", 100000, "0", "0-150,000"},
+		{"at tax free boundary", 150000, "0", "0-150,000"},
+		{"just over tax free boundary", 150001, "0.1", "150,001-500,000"},
+		{"at second bracket boundary", 500000, "0.1", "150,001-500,000"},
+		{"middle bracket", 700000, "0.15", "500,001-1,000,000"},
+		{"no upper limit", 5000000, "0.35", "2,000,001 ขึ้นไป"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bracket := getMarginalBracket(decimal.NewFromInt(tt.taxable), defaultBrackets)
+			assert.Equal(t, tt.expectedRate, bracket.Rate.String())
+			assert.Equal(t, tt.expectedBracket, getLevelDescription(bracket))
+		})
+	}
+}
@@ -1,5 +1,13 @@
 package tax
 
+const (
+	level1 = "level1"
+	level2 = "level2"
+	level3 = "level3"
+	level4 = "level4"
+	level5 = "level5"
+)
+
 func getMockTaxLevels(levelsToUpdate ...TaxLevel) []TaxLevel {
 	levelElementPositionMap := map[string]int{
 		level1: 0,
@@ -9,13 +17,7 @@ func getMockTaxLevels(levelsToUpdate ...TaxLevel) []TaxLevel {
 		level5: 4,
 	}
 
-	mockTaxLevels := []TaxLevel{
-		{getLevelDescription(level1), new(float64)},
-		{getLevelDescription(level2), new(float64)},
-		{getLevelDescription(level3), new(float64)},
-		{getLevelDescription(level4), new(float64)},
-		{getLevelDescription(level5), new(float64)},
-	}
+	mockTaxLevels := initializeTaxLevels(defaultBrackets)
 
 	for _, v := range levelsToUpdate {
 		mockTaxLevels[levelElementPositionMap[v.Level]].Tax = v.Tax
@@ -0,0 +1,162 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/Atvit/assessment-tax/utils"
+	"github.com/shopspring/decimal"
+	"slices"
+)
+
+const monthsInYear = 12
+
+// MonthlyIncome is an amount paid from or in a month, numbered 1 to 12.
+type MonthlyIncome struct {
+	Month  int
+	Amount decimal.Decimal
+}
+
+type MonthlyWithholding struct {
+	Month       int
+	Salary      decimal.Decimal
+	Bonus       decimal.Decimal
+	Withholding decimal.Decimal
+}
+
+type WithholdingProjection struct {
+	Months           []MonthlyWithholding
+	AnnualIncome     decimal.Decimal
+	AnnualTax        decimal.Decimal
+	TotalWithholding decimal.Decimal
+	OverWithheld     decimal.Decimal
+}
+
+// projectWithholding spreads the annual tax on salary over the months of the
+// year using the annualisation method for PND1. Each month estimates the annual
+// salary from what was paid so far plus the current salary for the remaining
+// months, and withholds the tax not yet withheld evenly over those months. A
+// bonus is withheld in full in the month it is paid as the extra tax it causes.
+// December is trued up so the total equals the annual tax from calculateCopy. When
+// earlier months already withheld more than that, as after a salary cut,
+// December withholds nothing and the excess is reported as OverWithheld.
+func projectWithholding(t *Tax, salary decimal.Decimal, salaryChanges, bonuses []MonthlyIncome) (WithholdingProjection, error) {
+	salaries, err := getMonthlySalaries(salary, salaryChanges)
+	if err != nil {
+		return WithholdingProjection{}, err
+	}
+
+	monthlyBonuses, err := getMonthlyBonuses(bonuses)
+	if err != nil {
+		return WithholdingProjection{}, err
+	}
+
+	ruleSet, err := t.getRuleSet()
+	if err != nil {
+		return WithholdingProjection{}, err
+	}
+
+	projection := WithholdingProjection{TotalWithholding: decimal.Zero, OverWithheld: decimal.Zero}
+	paid := decimal.Zero
+	for month := 1; month <= monthsInYear; month++ {
+		remainingMonths := decimal.NewFromInt(int64(monthsInYear - month + 1))
+		estimatedIncome := paid.Add(salaries[month-1].Mul(remainingMonths))
+
+		regularTax, err := calculateSalaryTax(t, estimatedIncome)
+		if err != nil {
+			return WithholdingProjection{}, err
+		}
+		regular := decimal.Max(regularTax.Sub(projection.TotalWithholding).Div(remainingMonths), decimal.Zero)
+
+		bonus := decimal.Zero
+		if bonusAmount := monthlyBonuses[month-1]; bonusAmount.IsPositive() {
+			bonusTax, err := calculateSalaryTax(t, estimatedIncome.Add(bonusAmount))
+			if err != nil {
+				return WithholdingProjection{}, err
+			}
+			bonus = bonusTax.Sub(regularTax)
+		}
+
+		projection.Months = append(projection.Months, MonthlyWithholding{
+			Month:       month,
+			Salary:      salaries[month-1],
+			Bonus:       monthlyBonuses[month-1],
+			Withholding: utils.Round(regular.Add(bonus), ruleSet.Precision),
+		})
+
+		paid = paid.Add(salaries[month-1]).Add(monthlyBonuses[month-1])
+		projection.TotalWithholding = projection.TotalWithholding.Add(projection.Months[month-1].Withholding)
+	}
+
+	annualTax, err := calculateSalaryTax(t, paid)
+	if err != nil {
+		return WithholdingProjection{}, err
+	}
+
+	december := &projection.Months[monthsInYear-1]
+	trueUp := decimal.Max(annualTax.Sub(projection.TotalWithholding), december.Withholding.Neg())
+	december.Withholding = december.Withholding.Add(trueUp)
+
+	projection.AnnualIncome = paid
+	projection.AnnualTax = annualTax
+	projection.TotalWithholding = projection.TotalWithholding.Add(trueUp)
+	projection.OverWithheld = decimal.Max(projection.TotalWithholding.Sub(annualTax), decimal.Zero)
+
+	return projection, nil
+}
+
+func getMonthlySalaries(salary decimal.Decimal, changes []MonthlyIncome) ([]decimal.Decimal, error) {
+	changes = slices.Clone(changes)
+	slices.SortStableFunc(changes, func(a, b MonthlyIncome) int {
+		return a.Month - b.Month
+	})
+
+	salaries := make([]decimal.Decimal, monthsInYear)
+	for month := 1; month <= monthsInYear; month++ {
+		for len(changes) > 0 && changes[0].Month <= month {
+			if changes[0].Month < 1 {
+				return nil, errs.ErrIncorrectMonth
+			}
+			salary = changes[0].Amount
+			changes = changes[1:]
+		}
+
+		salaries[month-1] = salary
+	}
+
+	if len(changes) > 0 {
+		return nil, errs.ErrIncorrectMonth
+	}
+
+	return salaries, nil
+}
+
+func getMonthlyBonuses(bonuses []MonthlyIncome) ([]decimal.Decimal, error) {
+	amounts := make([]decimal.Decimal, monthsInYear)
+	for i := range amounts {
+		amounts[i] = decimal.Zero
+	}
+
+	for _, bonus := range bonuses {
+		if bonus.Month < 1 || bonus.Month > monthsInYear {
+			return nil, errs.ErrIncorrectMonth
+		}
+
+		amounts[bonus.Month-1] = amounts[bonus.Month-1].Add(bonus.Amount)
+	}
+
+	return amounts, nil
+}
+
+func calculateSalaryTax(t *Tax, income decimal.Decimal) (decimal.Decimal, error) {
+	tax := *t
+	tax.Income = income
+	tax.Incomes = []Income{{Category: salaryIncome, Amount: income}}
+	tax.Wht = decimal.Zero
+	tax.Allowances = slices.Clone(t.Allowances)
+
+	result, err := calculateCopy(&tax)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return result.Tax, nil
+}
@@ -0,0 +1,120 @@
+package tax
+
+import (
+	"github.com/Atvit/assessment-tax/errs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProjectWithholding(t *testing.T) {
+	tests := []struct {
+		name                 string
+		salary               int64
+		salaryChanges        []MonthlyIncome
+		bonuses              []MonthlyIncome
+		allowances           []Allowance
+		expectedAnnualTax    string
+		expectedOverWithheld string
+		expectedWithholding  []string
+		expectedErr          error
+	}{
+		{
+			name:                 "flat salary",
+			salary:               50000,
+			expectedAnnualTax:    "29000",
+			expectedOverWithheld: "0",
+			expectedWithholding: []string{
+				"2416.7", "2416.7", "2416.7", "2416.7", "2416.7", "2416.6",
+				"2416.7", "2416.6", "2416.7", "2416.6", "2416.7", "2416.6",
+			},
+		},
+		{
+			name:                 "salary raised mid-year",
+			salary:               50000,
+			salaryChanges:        []MonthlyIncome{{Month: 7, Amount: decimal.NewFromInt(80000)}},
+			expectedAnnualTax:    "53000",
+			expectedOverWithheld: "0",
+			expectedWithholding: []string{
+				"2416.7", "2416.7", "2416.7", "2416.7", "2416.7", "2416.6",
+				"6416.7", "6416.6", "6416.7", "6416.6", "6416.7", "6416.6",
+			},
+		},
+		{
+			name:                 "bonus withheld in the month paid",
+			salary:               50000,
+			bonuses:              []MonthlyIncome{{Month: 3, Amount: decimal.NewFromInt(100000)}},
+			expectedAnnualTax:    "41000",
+			expectedOverWithheld: "0",
+			expectedWithholding: []string{
+				"2416.7", "2416.7", "14416.7", "2416.7", "2416.7", "2416.6",
+				"2416.7", "2416.6", "2416.7", "2416.6", "2416.7", "2416.6",
+			},
+		},
+		{
+			name:                 "allowances reduce withholding",
+			salary:               50000,
+			allowances:           []Allowance{{AllowanceType: kReceipt, Amount: decimal.NewFromInt(50000)}},
+			expectedAnnualTax:    "24000",
+			expectedOverWithheld: "0",
+			expectedWithholding: []string{
+				"2000", "2000", "2000", "2000", "2000", "2000",
+				"2000", "2000", "2000", "2000", "2000", "2000",
+			},
+		},
+		{
+			name:                 "salary cut mid-year",
+			salary:               100000,
+			salaryChanges:        []MonthlyIncome{{Month: 7, Amount: decimal.NewFromInt(10000)}},
+			expectedAnnualTax:    "35000",
+			expectedOverWithheld: "23999.9",
+			expectedWithholding: []string{
+				"9833.3", "9833.3", "9833.3", "9833.3", "9833.4", "9833.3",
+				"0", "0", "0", "0", "0", "0",
+			},
+		},
+		{
+			name:                 "tax free salary",
+			salary:               20000,
+			expectedAnnualTax:    "0",
+			expectedOverWithheld: "0",
+			expectedWithholding: []string{
+				"0", "0", "0", "0", "0", "0",
+				"0", "0", "0", "0", "0", "0",
+			},
+		},
+		{
+			name:          "salary change after december",
+			salary:        50000,
+			salaryChanges: []MonthlyIncome{{Month: 13, Amount: decimal.NewFromInt(80000)}},
+			expectedErr:   errs.ErrIncorrectMonth,
+		},
+		{
+			name:        "bonus before january",
+			salary:      50000,
+			bonuses:     []MonthlyIncome{{Month: 0, Amount: decimal.NewFromInt(100000)}},
+			expectedErr: errs.ErrIncorrectMonth,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projection, err := NewCalculator().ProjectWithholding(Tax{Allowances: tt.allowances}, decimal.NewFromInt(tt.salary), tt.salaryChanges, tt.bonuses)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedAnnualTax, projection.AnnualTax.String())
+			assert.Equal(t, tt.expectedOverWithheld, projection.OverWithheld.String())
+			assert.Equal(t, projection.AnnualTax.Add(projection.OverWithheld).String(), projection.TotalWithholding.String())
+
+			withholding := make([]string, 0, len(projection.Months))
+			for _, month := range projection.Months {
+				withholding = append(withholding, month.Withholding.String())
+			}
+			assert.Equal(t, tt.expectedWithholding, withholding)
+		})
+	}
+}
@@ -7,15 +7,19 @@ import (
 	"github.com/Atvit/assessment-tax/internals/tax"
 	"github.com/Atvit/assessment-tax/log"
 	"github.com/Atvit/assessment-tax/server"
-	"github.com/go-playground/validator/v10"
+	"github.com/Atvit/assessment-tax/utils"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 )
 
 func main() {
+	// amounts are written to JSON as exact numbers instead of quoted strings
+	decimal.MarshalJSONWithoutQuotes = true
+
 	e := echo.New()
 	logger := log.New()
 	cfg := config.New(logger)
-	validate := validator.New()
+	validate := utils.NewValidator()
 	db := db.New(cfg, logger)
 
 	conn, err := db.Connect()
@@ -12,6 +12,78 @@ type Handler struct {
 	mock.Mock
 }
 
+// CreateBrackets provides a mock function with given fields: c
+func (_m *Handler) CreateBrackets(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBrackets")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteBrackets provides a mock function with given fields: c
+func (_m *Handler) DeleteBrackets(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBrackets")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetBrackets provides a mock function with given fields: c
+func (_m *Handler) GetBrackets(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBrackets")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateBrackets provides a mock function with given fields: c
+func (_m *Handler) UpdateBrackets(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBrackets")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateKReceiptDeduction provides a mock function with given fields: c
 func (_m *Handler) UpdateKReceiptDeduction(c echo.Context) error {
 	ret := _m.Called(c)
@@ -48,6 +120,24 @@ func (_m *Handler) UpdatePersonalDeduction(c echo.Context) error {
 	return r0
 }
 
+// UploadExchangeRates provides a mock function with given fields: c
+func (_m *Handler) UploadExchangeRates(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UploadExchangeRates")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewHandler creates a new instance of Handler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewHandler(t interface {
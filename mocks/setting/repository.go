@@ -3,8 +3,13 @@
 package mocks
 
 import (
-	models "github.com/Atvit/assessment-tax/internals/models"
+	decimal "github.com/shopspring/decimal"
+
 	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/Atvit/assessment-tax/internals/models"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -12,6 +17,54 @@ type Repository struct {
 	mock.Mock
 }
 
+// CreateBrackets provides a mock function with given fields: taxYear, brackets
+func (_m *Repository) CreateBrackets(taxYear int, brackets []models.TaxBracket) ([]models.TaxBracket, error) {
+	ret := _m.Called(taxYear, brackets)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBrackets")
+	}
+
+	var r0 []models.TaxBracket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, []models.TaxBracket) ([]models.TaxBracket, error)); ok {
+		return rf(taxYear, brackets)
+	}
+	if rf, ok := ret.Get(0).(func(int, []models.TaxBracket) []models.TaxBracket); ok {
+		r0 = rf(taxYear, brackets)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TaxBracket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, []models.TaxBracket) error); ok {
+		r1 = rf(taxYear, brackets)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteBrackets provides a mock function with given fields: taxYear
+func (_m *Repository) DeleteBrackets(taxYear int) error {
+	ret := _m.Called(taxYear)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBrackets")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(taxYear)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields:
 func (_m *Repository) Get() (*models.DeductionConfig, error) {
 	ret := _m.Called()
@@ -42,8 +95,128 @@ func (_m *Repository) Get() (*models.DeductionConfig, error) {
 	return r0, r1
 }
 
+// GetBrackets provides a mock function with given fields: taxYear
+func (_m *Repository) GetBrackets(taxYear int) ([]models.TaxBracket, error) {
+	ret := _m.Called(taxYear)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBrackets")
+	}
+
+	var r0 []models.TaxBracket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]models.TaxBracket, error)); ok {
+		return rf(taxYear)
+	}
+	if rf, ok := ret.Get(0).(func(int) []models.TaxBracket); ok {
+		r0 = rf(taxYear)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TaxBracket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(taxYear)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExchangeRate provides a mock function with given fields: currency, date
+func (_m *Repository) GetExchangeRate(currency string, date time.Time) (*models.ExchangeRate, error) {
+	ret := _m.Called(currency, date)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetExchangeRate")
+	}
+
+	var r0 *models.ExchangeRate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) (*models.ExchangeRate, error)); ok {
+		return rf(currency, date)
+	}
+	if rf, ok := ret.Get(0).(func(string, time.Time) *models.ExchangeRate); ok {
+		r0 = rf(currency, date)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ExchangeRate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(currency, date)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceBrackets provides a mock function with given fields: taxYear, brackets
+func (_m *Repository) ReplaceBrackets(taxYear int, brackets []models.TaxBracket) ([]models.TaxBracket, error) {
+	ret := _m.Called(taxYear, brackets)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceBrackets")
+	}
+
+	var r0 []models.TaxBracket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, []models.TaxBracket) ([]models.TaxBracket, error)); ok {
+		return rf(taxYear, brackets)
+	}
+	if rf, ok := ret.Get(0).(func(int, []models.TaxBracket) []models.TaxBracket); ok {
+		r0 = rf(taxYear, brackets)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TaxBracket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, []models.TaxBracket) error); ok {
+		r1 = rf(taxYear, brackets)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveExchangeRates provides a mock function with given fields: rates
+func (_m *Repository) SaveExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	ret := _m.Called(rates)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveExchangeRates")
+	}
+
+	var r0 []models.ExchangeRate
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]models.ExchangeRate) ([]models.ExchangeRate, error)); ok {
+		return rf(rates)
+	}
+	if rf, ok := ret.Get(0).(func([]models.ExchangeRate) []models.ExchangeRate); ok {
+		r0 = rf(rates)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ExchangeRate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]models.ExchangeRate) error); ok {
+		r1 = rf(rates)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateKReceiptDeduction provides a mock function with given fields: id, value
-func (_m *Repository) UpdateKReceiptDeduction(id uint, value float64) (*models.DeductionConfig, error) {
+func (_m *Repository) UpdateKReceiptDeduction(id uint, value decimal.Decimal) (*models.DeductionConfig, error) {
 	ret := _m.Called(id, value)
 
 	if len(ret) == 0 {
@@ -52,10 +225,10 @@ func (_m *Repository) UpdateKReceiptDeduction(id uint, value float64) (*models.D
 
 	var r0 *models.DeductionConfig
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uint, float64) (*models.DeductionConfig, error)); ok {
+	if rf, ok := ret.Get(0).(func(uint, decimal.Decimal) (*models.DeductionConfig, error)); ok {
 		return rf(id, value)
 	}
-	if rf, ok := ret.Get(0).(func(uint, float64) *models.DeductionConfig); ok {
+	if rf, ok := ret.Get(0).(func(uint, decimal.Decimal) *models.DeductionConfig); ok {
 		r0 = rf(id, value)
 	} else {
 		if ret.Get(0) != nil {
@@ -63,7 +236,7 @@ func (_m *Repository) UpdateKReceiptDeduction(id uint, value float64) (*models.D
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(uint, float64) error); ok {
+	if rf, ok := ret.Get(1).(func(uint, decimal.Decimal) error); ok {
 		r1 = rf(id, value)
 	} else {
 		r1 = ret.Error(1)
@@ -73,7 +246,7 @@ func (_m *Repository) UpdateKReceiptDeduction(id uint, value float64) (*models.D
 }
 
 // UpdatePersonalDeduction provides a mock function with given fields: id, value
-func (_m *Repository) UpdatePersonalDeduction(id uint, value float64) (*models.DeductionConfig, error) {
+func (_m *Repository) UpdatePersonalDeduction(id uint, value decimal.Decimal) (*models.DeductionConfig, error) {
 	ret := _m.Called(id, value)
 
 	if len(ret) == 0 {
@@ -82,10 +255,10 @@ func (_m *Repository) UpdatePersonalDeduction(id uint, value float64) (*models.D
 
 	var r0 *models.DeductionConfig
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uint, float64) (*models.DeductionConfig, error)); ok {
+	if rf, ok := ret.Get(0).(func(uint, decimal.Decimal) (*models.DeductionConfig, error)); ok {
 		return rf(id, value)
 	}
-	if rf, ok := ret.Get(0).(func(uint, float64) *models.DeductionConfig); ok {
+	if rf, ok := ret.Get(0).(func(uint, decimal.Decimal) *models.DeductionConfig); ok {
 		r0 = rf(id, value)
 	} else {
 		if ret.Get(0) != nil {
@@ -93,7 +266,7 @@ func (_m *Repository) UpdatePersonalDeduction(id uint, value float64) (*models.D
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(uint, float64) error); ok {
+	if rf, ok := ret.Get(1).(func(uint, decimal.Decimal) error); ok {
 		r1 = rf(id, value)
 	} else {
 		r1 = ret.Error(1)
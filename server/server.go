@@ -60,9 +60,21 @@ func (s server) registerRoutes() {
 	}))
 	admin.POST("/deductions/personal", s.settingHandler.UpdatePersonalDeduction)
 	admin.POST("/deductions/k-receipt", s.settingHandler.UpdateKReceiptDeduction)
+	admin.GET("/brackets/:taxYear", s.settingHandler.GetBrackets)
+	admin.POST("/brackets/:taxYear", s.settingHandler.CreateBrackets)
+	admin.PUT("/brackets/:taxYear", s.settingHandler.UpdateBrackets)
+	admin.DELETE("/brackets/:taxYear", s.settingHandler.DeleteBrackets)
+	admin.POST("/exchange-rates", s.settingHandler.UploadExchangeRates)
 
 	tax := e.Group("/tax/calculations")
 	tax.POST("", s.taxHandler.CalculateTax)
+	tax.POST("/goal-seek", s.taxHandler.GoalSeek)
+	tax.POST("/advice", s.taxHandler.Advise)
+	tax.POST("/withholding", s.taxHandler.ProjectWithholding)
+	tax.POST("/filing-comparison", s.taxHandler.CompareFiling)
+	tax.POST("/compare", s.taxHandler.CompareScenarios)
+	tax.POST("/penalty", s.taxHandler.CalculatePenalty)
+	tax.POST("/payout", s.taxHandler.CalculatePayout)
 	tax.POST("/upload-csv", s.taxHandler.UploadCSV)
 }
 
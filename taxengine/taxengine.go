@@ -0,0 +1,55 @@
+// Package taxengine calculates Thai personal income tax without running the
+// HTTP server. It is the Calculator the server's handlers use.
+package taxengine
+
+import (
+	"github.com/Atvit/assessment-tax/internals/tax"
+)
+
+type (
+	Calculator            = tax.Calculator
+	Option                = tax.Option
+	Tax                   = tax.Tax
+	Income                = tax.Income
+	Allowance             = tax.Allowance
+	AllowanceSetting      = tax.AllowanceSetting
+	Dividend              = tax.Dividend
+	RuleSet               = tax.RuleSet
+	Bracket               = tax.Bracket
+	Result                = tax.Result
+	Advice                = tax.Advice
+	GoalSeekResult        = tax.GoalSeekResult
+	MonthlyIncome         = tax.MonthlyIncome
+	WithholdingProjection = tax.WithholdingProjection
+	Filer                 = tax.Filer
+	FilingComparison      = tax.FilingComparison
+	Penalty               = tax.Penalty
+	Payout                = tax.Payout
+	PayoutResult          = tax.PayoutResult
+)
+
+func New(opts ...Option) Calculator {
+	return tax.NewCalculator(opts...)
+}
+
+func WithRuleSet(ruleSet RuleSet) Option {
+	return tax.WithRuleSet(ruleSet)
+}
+
+func WithPrecision(places int32) Option {
+	return tax.WithPrecision(places)
+}
+
+func WithAllowanceSetting(setting AllowanceSetting) Option {
+	return tax.WithAllowanceSetting(setting)
+}
+
+func WithBrackets(brackets []Bracket) Option {
+	return tax.WithBrackets(brackets)
+}
+
+// GetRuleSet returns the rule set of the Buddhist Era tax year, the default
+// year when taxYear is 0.
+func GetRuleSet(taxYear int) (RuleSet, error) {
+	return tax.GetRuleSet(taxYear)
+}
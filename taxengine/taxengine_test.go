@@ -0,0 +1,39 @@
+package taxengine
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalculator(t *testing.T) {
+	ruleSet, err := GetRuleSet(2567)
+	assert.NoError(t, err)
+
+	calculator := New(
+		WithRuleSet(ruleSet),
+		WithPrecision(0),
+		WithAllowanceSetting(AllowanceSetting{Personal: decimal.NewFromInt(60000)}),
+	)
+
+	result, err := calculator.Calculate(Tax{
+		Income:     decimal.RequireFromString("500005.5"),
+		Wht:        decimal.NewFromInt(25000),
+		Allowances: []Allowance{{AllowanceType: "donation", Amount: decimal.NewFromInt(100000)}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "396004.95", result.TaxableIncome.String())
+	assert.Equal(t, "0", result.Tax.String())
+	assert.Equal(t, "400", result.Refund.String())
+}
+
+func TestCalculator_ProjectWithholding(t *testing.T) {
+	projection, err := New(WithPrecision(0)).ProjectWithholding(Tax{}, decimal.NewFromInt(50000), nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "29000", projection.TotalWithholding.String())
+	for _, month := range projection.Months {
+		assert.True(t, month.Withholding.Equal(month.Withholding.Round(0)), "month %d withholds %s", month.Month, month.Withholding)
+	}
+}
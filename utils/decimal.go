@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+	"reflect"
+)
+
+// decimalValue is what the validator sees of a decimal.Decimal field. Zero is
+// empty so required and omitempty behave as they do for numbers, and the
+// comparisons parse it back so amounts are compared exactly.
+type decimalValue string
+
+var decimalComparisons = map[string]func(value1, value2 decimal.Decimal) bool{
+	"gt":  Gt,
+	"gte": Gte,
+	"lt":  Lt,
+	"lte": Lte,
+}
+
+func decimalTypeFunc(field reflect.Value) interface{} {
+	if value, ok := field.Interface().(decimal.Decimal); ok {
+		if value.IsZero() {
+			return decimalValue("")
+		}
+		return decimalValue(value.String())
+	}
+
+	return nil
+}
+
+// registerDecimalComparisons replaces the comparison tags with ones that
+// compare decimal fields as decimals. Other fields are checked by the
+// validator's own tags.
+func registerDecimalComparisons(validate *validator.Validate) {
+	builtIn := validator.New()
+
+	for tag, compare := range decimalComparisons {
+		validate.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			value, ok := toDecimal(fl.Field())
+			if !ok {
+				return builtIn.Var(fl.Field().Interface(), tag+"="+fl.Param()) == nil
+			}
+
+			param, err := decimal.NewFromString(fl.Param())
+			return err == nil && compare(value, param)
+		})
+	}
+
+	validate.RegisterValidation("ltefield", func(fl validator.FieldLevel) bool {
+		other, _, _, ok := fl.GetStructFieldOK2()
+		if !ok {
+			return false
+		}
+
+		value, ok := toDecimal(fl.Field())
+		otherValue, otherOk := toDecimal(other)
+		if !ok || !otherOk {
+			return builtIn.VarWithValue(fl.Field().Interface(), other.Interface(), "ltefield") == nil
+		}
+
+		return Lte(value, otherValue)
+	})
+}
+
+func toDecimal(field reflect.Value) (decimal.Decimal, bool) {
+	value, ok := field.Interface().(decimalValue)
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	if value == "" {
+		return decimal.Zero, true
+	}
+
+	amount, err := decimal.NewFromString(string(value))
+	return amount, err == nil
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"github.com/shopspring/decimal"
+	"strconv"
+	"strings"
+)
+
+func FormatNumber(num decimal.Decimal) string {
+	digits := strconv.FormatInt(num.IntPart(), 10)
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+
+	return sign + b.String()
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormatNumber(t *testing.T) {
+	type testcase struct {
+		Name     string
+		Value    float64
+		Expected string
+	}
+
+	tcs := []testcase{
+		{"zero", 0, "0"},
+		{"less than one thousand", 999, "999"},
+		{"thousands", 150001, "150,001"},
+		{"millions", 2000000, "2,000,000"},
+		{"negative", -1500000, "-1,500,000"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			result := FormatNumber(decimal.NewFromFloat(tc.Value))
+
+			assert.Equal(t, tc.Expected, result)
+		})
+	}
+}
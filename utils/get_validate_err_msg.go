@@ -13,8 +13,11 @@ const (
 	oneof    = "the value of %s must be one of %s"
 	gt       = "the value of %s must be greater than %s"
 	gte      = "the value of %s must be greater than or equal %s"
+	lt       = "the value of %s must be less than %s"
 	lte      = "the value of %s must be less than or equal %s"
 	ltefield = "the value of %s value must be lower than or equal value of field %s"
+	datetime = "the value of %s must be a date in format %s"
+	length   = "the length of %s must be %s"
 )
 
 type FieldErr struct {
@@ -32,10 +35,16 @@ func getErrMsg(fe validator.FieldError) string {
 		return fmt.Sprintf(gt, fe.Field(), fe.Param())
 	case "gte":
 		return fmt.Sprintf(gte, fe.Field(), fe.Param())
+	case "lt":
+		return fmt.Sprintf(lt, fe.Field(), fe.Param())
 	case "lte":
 		return fmt.Sprintf(lte, fe.Field(), fe.Param())
 	case "ltefield":
 		return fmt.Sprintf(ltefield, fe.Field(), fe.Param())
+	case "datetime":
+		return fmt.Sprintf(datetime, fe.Field(), fe.Param())
+	case "len":
+		return fmt.Sprintf(length, fe.Field(), fe.Param())
 	}
 
 	return UnknownErrMsg
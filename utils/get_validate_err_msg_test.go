@@ -77,7 +77,10 @@ func TestGetErrMsg(t *testing.T) {
 		{"gt", "Age", "18", "the value of Age must be greater than 18"},
 		{"gte", "Members", "1", "the value of Members must be greater than or equal 1"},
 		{"ltefield", "StartYear", "EndYear", "the value of StartYear value must be lower than or equal value of field EndYear"},
+		{"lt", "Rate", "1", "the value of Rate must be less than 1"},
 		{"lte", "Age", "18", "the value of Age must be less than or equal 18"},
+		{"datetime", "FilingDate", "2006-01-02", "the value of FilingDate must be a date in format 2006-01-02"},
+		{"len", "Currency", "3", "the length of Currency must be 3"},
 		{"unknown", "Field", "Param", UnknownErrMsg},
 	}
 
@@ -1,9 +1,7 @@
 package utils
 
-import "math"
+import "github.com/shopspring/decimal"
 
-func Round(num float64, precision int) float64 {
-	power := math.Pow10(precision)
-	rounded := math.Round(num*power) / power
-	return rounded
+func Round(num decimal.Decimal, precision int32) decimal.Decimal {
+	return num.Round(precision)
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -8,21 +9,23 @@ import (
 func TestRound(t *testing.T) {
 	type testcase struct {
 		Name      string
-		Value     float64
-		Precision int
-		Expected  float64
+		Value     string
+		Precision int32
+		Expected  string
 	}
 
 	tcs := []testcase{
-		{"round positive float", 54.23456, 2, 54.23},
-		{"round negative float", -54.761, 2, -54.76},
+		{"round positive decimal", "54.23456", 2, "54.23"},
+		{"round negative decimal", "-54.761", 2, "-54.76"},
+		{"round half away from zero", "29000.05", 1, "29000.1"},
+		{"keep large amount exact", "123456789012.345", 2, "123456789012.35"},
 	}
 
 	for _, tc := range tcs {
 		t.Run(tc.Name, func(t *testing.T) {
-			result := Round(tc.Value, tc.Precision)
+			result := Round(decimal.RequireFromString(tc.Value), tc.Precision)
 
-			assert.Equal(t, tc.Expected, result)
+			assert.Equal(t, tc.Expected, result.String())
 		})
 	}
 }
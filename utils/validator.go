@@ -1,19 +1,33 @@
 package utils
 
 import (
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
 	"golang.org/x/exp/slices"
 )
 
-func Gte(value1, value2 float64) bool {
-	return value1 >= value2
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterCustomTypeFunc(decimalTypeFunc, decimal.Decimal{})
+	registerDecimalComparisons(validate)
+
+	return validate
+}
+
+func Gte(value1, value2 decimal.Decimal) bool {
+	return value1.GreaterThanOrEqual(value2)
+}
+
+func Lte(value1, value2 decimal.Decimal) bool {
+	return value1.LessThanOrEqual(value2)
 }
 
-func Lte(value1, value2 float64) bool {
-	return value1 <= value2
+func Gt(value1, value2 decimal.Decimal) bool {
+	return value1.GreaterThan(value2)
 }
 
-func Gt(value1, value2 float64) bool {
-	return value1 > value2
+func Lt(value1, value2 decimal.Decimal) bool {
+	return value1.LessThan(value2)
 }
 
 func Oneof(value string, items ...string) bool {
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -21,7 +22,7 @@ func TestGte(t *testing.T) {
 
 	for _, tc := range tcs {
 		t.Run(tc.Name, func(t *testing.T) {
-			result := Gte(tc.Value1, tc.Value2)
+			result := Gte(decimal.NewFromFloat(tc.Value1), decimal.NewFromFloat(tc.Value2))
 
 			assert.Equal(t, tc.Expected, result)
 		})
@@ -44,7 +45,7 @@ func TestLte(t *testing.T) {
 
 	for _, tc := range tcs {
 		t.Run(tc.Name, func(t *testing.T) {
-			result := Lte(tc.Value1, tc.Value2)
+			result := Lte(decimal.NewFromFloat(tc.Value1), decimal.NewFromFloat(tc.Value2))
 
 			assert.Equal(t, tc.Expected, result)
 		})
@@ -67,7 +68,30 @@ func TestGt(t *testing.T) {
 
 	for _, tc := range tcs {
 		t.Run(tc.Name, func(t *testing.T) {
-			result := Gt(tc.Value1, tc.Value2)
+			result := Gt(decimal.NewFromFloat(tc.Value1), decimal.NewFromFloat(tc.Value2))
+
+			assert.Equal(t, tc.Expected, result)
+		})
+	}
+}
+
+func TestLt(t *testing.T) {
+	type testcase struct {
+		Name     string
+		Value1   float64
+		Value2   float64
+		Expected bool
+	}
+
+	tcs := []testcase{
+		{"value 1 greater than value 2", 5, 4, false},
+		{"value 1 equal value 2", 5, 5, false},
+		{"value 1 less than value 2", 4, 5, true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			result := Lt(decimal.NewFromFloat(tc.Value1), decimal.NewFromFloat(tc.Value2))
 
 			assert.Equal(t, tc.Expected, result)
 		})
@@ -98,3 +122,39 @@ func TestOneof(t *testing.T) {
 		})
 	}
 }
+
+func TestNewValidator(t *testing.T) {
+	type request struct {
+		TotalIncome decimal.Decimal `validate:"required,gte=0"`
+		Wht         decimal.Decimal `validate:"omitempty,gte=0,ltefield=TotalIncome"`
+		Rate        decimal.Decimal `validate:"omitempty,gt=0,lte=1"`
+		Month       int             `validate:"omitempty,gte=1,lte=12"`
+	}
+
+	type testcase struct {
+		Name        string
+		Request     request
+		ExpectedErr bool
+	}
+
+	tcs := []testcase{
+		{"valid decimal fields", request{decimal.NewFromInt(500000), decimal.NewFromInt(25000), decimal.Zero, 0}, false},
+		{"required decimal field", request{decimal.Zero, decimal.Zero, decimal.Zero, 0}, true},
+		{"negative decimal field", request{decimal.NewFromInt(-1), decimal.Zero, decimal.Zero, 0}, true},
+		{"decimal field greater than other field", request{decimal.NewFromInt(5000), decimal.NewFromInt(6000), decimal.Zero, 0}, true},
+		{"decimal field compared exactly to other field", request{decimal.NewFromInt(100000), decimal.RequireFromString("100000.000000000001"), decimal.Zero, 0}, true},
+		{"decimal field compared exactly to param", request{decimal.NewFromInt(500000), decimal.Zero, decimal.RequireFromString("1.0000000000000001"), 0}, true},
+		{"decimal field equal to param", request{decimal.NewFromInt(500000), decimal.Zero, decimal.NewFromInt(1), 0}, false},
+		{"int field within range", request{decimal.NewFromInt(500000), decimal.Zero, decimal.Zero, 12}, false},
+		{"int field out of range", request{decimal.NewFromInt(500000), decimal.Zero, decimal.Zero, 13}, true},
+	}
+
+	validate := NewValidator()
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := validate.Struct(tc.Request)
+
+			assert.Equal(t, tc.ExpectedErr, err != nil)
+		})
+	}
+}